@@ -0,0 +1,123 @@
+package docparse
+
+import (
+	"go/doc/comment"
+	"regexp"
+	"strings"
+)
+
+// ParseDoc parses the documentation s with the Go doc comment syntax:
+// paragraphs, lists, preformatted blocks, links, and doc links such as
+// [pkg.Type].
+//
+// Doc links to one of the references in prog can be found with RefLink.
+func ParseDoc(prog *Program, s string) *comment.Doc {
+	p := comment.Parser{
+		LookupPackage: func(name string) (string, bool) {
+			for k := range prog.References {
+				if strings.HasPrefix(k, name+".") {
+					return name, true
+				}
+			}
+			return "", false
+		},
+	}
+	return p.Parse(s)
+}
+
+// RefLink gets the reference for a doc link, or an empty string if it doesn't
+// refer to one of the references in prog.
+func RefLink(prog *Program, link *comment.DocLink) string {
+	if link.Recv != "" {
+		return ""
+	}
+	lookup := link.ImportPath
+	if i := strings.LastIndex(lookup, "/"); i > -1 {
+		lookup = lookup[i+1:]
+	}
+	lookup += "." + link.Name
+	if _, ok := prog.References[lookup]; !ok {
+		return ""
+	}
+	return lookup
+}
+
+// Markdown converts the doc links in the documentation s to CommonMark, for
+// output formats that use that in descriptions. Everything else is kept as
+// written.
+//
+// Doc links to references are written as just the name, and other doc links
+// link to pkg.go.dev.
+func Markdown(prog *Program, s string) string {
+	if !strings.Contains(s, "[") {
+		return s
+	}
+
+	links := make(map[string]string)
+	var find func([]comment.Block)
+	find = func(blocks []comment.Block) {
+		for _, b := range blocks {
+			switch b := b.(type) {
+			case *comment.Paragraph:
+				docLinks(prog, b.Text, links)
+			case *comment.Heading:
+				docLinks(prog, b.Text, links)
+			case *comment.List:
+				for _, item := range b.Items {
+					find(item.Content)
+				}
+			}
+		}
+	}
+	find(ParseDoc(prog, s).Content)
+	if len(links) == 0 {
+		return s
+	}
+
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		// Preformatted block.
+		if strings.HasPrefix(line, "\t") || strings.HasPrefix(line, "    ") {
+			continue
+		}
+		lines[i] = reDocLink.ReplaceAllStringFunc(line, func(m string) string {
+			// Already a Markdown link.
+			if strings.HasSuffix(m, "(") {
+				return m
+			}
+			if l, ok := links[m[1:len(m)-1]]; ok {
+				return l
+			}
+			return m
+		})
+	}
+	return strings.Join(lines, "\n")
+}
+
+var reDocLink = regexp.MustCompile(`\[[^\[\]\n]+\]\(?`)
+
+// Add the Markdown for all doc links in text to links, keyed by the link text.
+func docLinks(prog *Program, text []comment.Text, links map[string]string) {
+	for _, t := range text {
+		l, ok := t.(*comment.DocLink)
+		if !ok {
+			continue
+		}
+		var b strings.Builder
+		for _, tt := range l.Text {
+			switch tt := tt.(type) {
+			case comment.Plain:
+				b.WriteString(string(tt))
+			case comment.Italic:
+				b.WriteString(string(tt))
+			}
+		}
+		text := b.String()
+
+		if RefLink(prog, l) != "" {
+			links[text] = text
+		} else {
+			links[text] = "[" + text + "](" + l.DefaultURL("https://pkg.go.dev") + ")"
+		}
+	}
+}
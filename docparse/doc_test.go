@@ -0,0 +1,40 @@
+package docparse
+
+import (
+	"testing"
+
+	"zgo.at/zstd/ztest"
+)
+
+func TestMarkdown(t *testing.T) {
+	prog := NewProgram(false)
+	prog.References["models.Bike"] = Reference{Schema: &Schema{}}
+
+	tests := []struct {
+		in, want string
+	}{
+		{"", ""},
+		{"Hello.", "Hello."},
+		{"Wrapped\nline.\n\nParagraph.", "Wrapped\nline.\n\nParagraph."},
+		{"List:\n\n  - one\n  - two", "List:\n\n  - one\n  - two"},
+		{"Code:\n\n\tfoo()", "Code:\n\n\tfoo()"},
+		{"Keep *emphasis*, `code`, <b> and $var", "Keep *emphasis*, `code`, <b> and $var"},
+		{"See [models.Bike].", "See models.Bike."},
+		{"See\n[models.Bike] and\n\n\t[models.Bike]", "See\nmodels.Bike and\n\n\t[models.Bike]"},
+		{"See [net/http.Header].", "See [net/http.Header](https://pkg.go.dev/net/http#Header)."},
+		{"See [net/http.Header](https://example.com).", "See [net/http.Header](https://example.com)."},
+		{"Not a [link].", "Not a [link]."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			out := Markdown(prog, tt.in)
+			if d := ztest.Diff(out, tt.want); d != "" {
+				t.Error(d)
+			}
+			if again := Markdown(prog, out); again != out {
+				t.Errorf("not idempotent: %q", again)
+			}
+		})
+	}
+}
@@ -2,14 +2,18 @@
 package docparse
 
 import (
+	"encoding/json"
 	"fmt"
 	"go/ast"
 	"go/token"
 	"html/template"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"os"
+	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -28,9 +32,10 @@ type Program struct {
 // Config for the program.
 type Config struct {
 	// Kommentaar control.
-	Packages []string
-	Output   func(io.Writer, *Program) error
-	Debug    bool
+	Packages  []string
+	Output    func(io.Writer, *Program) error
+	OutputDir string // For outputs that write more than one file.
+	Debug     bool
 
 	// General information.
 	Title        string
@@ -41,16 +46,37 @@ type Config struct {
 	ContactSite  string
 	Auth         string
 
+	// Where the API is served.
+	Host     string   // e.g. api.example.com
+	Schemes  []string // e.g. https
+	Servers  []Server
+	Consumes []string // Content-Types for all endpoints; DefaultRequestCt if blank.
+	Produces []string // Content-Types for all endpoints; DefaultResponseCt if blank.
+
 	// Defaults.
 	DefaultRequestCt   string
 	DefaultResponseCt  string
-	DefaultResponse    map[int]Response
-	AddDefaultResponse []int
+	DefaultResponse    map[string]Response
+	AddDefaultResponse []string
+	AddDefaultParams   map[string]*Ref // Keyed by location: query or header.
 	Prefix             string
 	Basepath           string
 	StructTag          string
 	MapTypes           map[string]string
 	MapFormats         map[string]string
+	MapSchemas         map[string]string
+	CollectionFormat   string
+	SplitReadWrite     bool
+	CodeSamples        []string // Command-line snippets for every endpoint; see the snippet package.
+
+	// HTML output.
+	HTMLTemplate string   // Template file to override the template or some blocks.
+	HTMLStyles   []string // Extra CSS files.
+	HTMLScripts  []string // Extra JavaScript files.
+	HTMLConsole  bool     // Add a "try it" console to send requests.
+
+	// Go client output.
+	GoClientPackage string // Package name; "client" if blank.
 }
 
 // DefaultResponse references.
@@ -67,6 +93,7 @@ func NewProgram(dbg bool) *Program {
 	// Clear cache; otherwise tests with -count 2 fail.
 	// TODO: figure out why; should work really.
 	declsCache = make(map[string][]declCache)
+	declsFset = token.NewFileSet()
 
 	return &Program{
 		References: make(map[string]Reference),
@@ -75,6 +102,7 @@ func NewProgram(dbg bool) *Program {
 			DefaultResponseCt: "application/json",
 			MapTypes:          make(map[string]string),
 			MapFormats:        make(map[string]string),
+			MapSchemas:        make(map[string]string),
 
 			// Override from commandline.
 			Debug: dbg,
@@ -95,26 +123,51 @@ type Endpoint struct {
 	Method    string   // HTTP method (e.g. POST, DELETE, etc.)
 	Path      string   // Request path.
 	Tags      []string // Tags for grouping (optional).
+	Auth      string   // Authentication: "basic" or "none"; Config.Auth is used if blank.
 	Tagline   string   // Single-line description (optional).
 	Info      string   // More detailed description (optional).
 	Request   Request
-	Responses map[int]Response
+	Responses map[string]Response // Keyed by status code, range ("5XX"), or "default".
 	Pos, End  token.Position
+
+	NoDefaultParams bool // Don't add Config.AddDefaultParams to this endpoint.
 }
 
 // Request definition.
 type Request struct {
-	ContentType string // Content-Type that this request accepts for the body.
-	Body        *Ref   // Request body; usually a JSON body.
-	Path        *Ref   // Path parameters (e.g. /foo/{id}).
-	Query       *Ref   // Query parameters  (e.g. ?foo=id).
-	Form        *Ref   // Form parameters.
+	Bodies          map[string]*Ref // Request bodies by Content-Type; usually a single JSON body.
+	Path            *Ref            // Path parameters (e.g. /foo/{id}).
+	Query           *Ref            // Query parameters  (e.g. ?foo=id).
+	Form            *Ref            // Form parameters.
+	FormContentType string          // Content-Type for the form; multipart/form-data if there are file uploads.
 }
 
 // Response definition.
 type Response struct {
-	ContentType string // Content-Type.
-	Body        *Ref   // Body.
+	Bodies      map[string]*Ref // Response bodies by Content-Type.
+	Description string          // Description; Summary() uses the body's description if this is blank.
+}
+
+// Summary gets the response description, or the description of the body for
+// the first Content-Type if there is no description (e.g. "200 OK").
+func (r Response) Summary() string {
+	if r.Description != "" {
+		return r.Description
+	}
+	if ct := ContentTypes(r.Bodies); len(ct) > 0 {
+		return r.Bodies[ct[0]].Description
+	}
+	return ""
+}
+
+// ContentTypes gets the Content-Types of the bodies, sorted.
+func ContentTypes(bodies map[string]*Ref) []string {
+	ct := make([]string, 0, len(bodies))
+	for k := range bodies {
+		ct = append(ct, k)
+	}
+	sort.Strings(ct)
+	return ct
 }
 
 // Ref parameters for the path, query, form, request body, or response body.
@@ -124,6 +177,9 @@ type Ref struct {
 	// it looks pretties in the pretty.Print() output. May not want to keep
 	// this.
 	Reference string //*Reference
+
+	Example json.RawMessage // Example request or response body.
+	Empty   bool            // Response without a body, from {empty}.
 }
 
 // Param is a path, query, or form parameter.
@@ -146,7 +202,7 @@ type Reference struct {
 	File    string  // File this struct resides in.
 	Lookup  string  // Identifier as pkg.type.
 	Info    string  // Comment of the struct itself.
-	Context string  // Context we found it: path, query, form, req, resp.
+	Context string  // Context we found it: path, query, form, header, req, resp.
 	IsEmbed bool    // Is an embedded struct.
 	Schema  *Schema // JSON schema.
 
@@ -154,11 +210,12 @@ type Reference struct {
 }
 
 const (
-	ctxForm  = "form"
-	ctxPath  = "path"
-	ctxQuery = "query"
-	ctxReq   = "req"
-	ctxResp  = "resp"
+	ctxForm   = "form"
+	ctxPath   = "path"
+	ctxQuery  = "query"
+	ctxHeader = "header"
+	ctxReq    = "req"
+	ctxResp   = "resp"
 
 	refDefault = "{default}"
 	refEmpty   = "{empty}"
@@ -170,7 +227,10 @@ var allRefs = []string{refDefault, refEmpty, refData}
 var (
 	reBasicHeader    = regexp.MustCompile(`^(Path|Form|Query): (.+)`)
 	reRequestHeader  = regexp.MustCompile(`^Request body( \((.+?)\))?: (.+)`)
-	reResponseHeader = regexp.MustCompile(`^Response( (\d+?))?( \((.+?)\))?: (.+)`)
+	reResponseHeader = regexp.MustCompile(`^Response( (\d+?|\dXX|default))?( \((.+?)\))?: (.+)`)
+	reRequestExample = regexp.MustCompile(`^Request example: (.+)`)
+	reRespExample    = regexp.MustCompile(`^Response( (\d+?|\dXX|default))? example: (.+)`)
+	reDefaultParams  = regexp.MustCompile(`^Default params: (.+)`)
 )
 
 // parseComment a single comment block in the file filePath.
@@ -217,11 +277,24 @@ func parseComment(prog *Program, comment, pkgPath, filePath string) ([]*Endpoint
 
 	pastDesc := false
 	var err error
+	var reqExample json.RawMessage
+	respExamples := map[string]json.RawMessage{}
+	lastResp := ""
 
 	// Get description and Kommentaar directives.
 	for _, line := range strings.Split(comment, "\n") {
 		i++
 
+		// Indented lines after a Response continue the description.
+		if lastResp != "" && strings.TrimSpace(line) != "" &&
+			(strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			r := e.Responses[lastResp]
+			r.Description = strings.TrimSpace(r.Description + " " + strings.TrimSpace(line))
+			e.Responses[lastResp] = r
+			continue
+		}
+		lastResp = ""
+
 		// Ignore blank lines after – but not in – the description.
 		if pastDesc && strings.TrimSpace(line) == "" {
 			continue
@@ -273,6 +346,15 @@ func parseComment(prog *Program, comment, pkgPath, filePath string) ([]*Endpoint
 					return nil, i, fmt.Errorf("%v already present", h[1])
 				}
 				e.Request.Form, err = parseRefValue(prog, "form", h[2], filePath)
+				if err == nil {
+					e.Request.FormContentType = "application/x-www-form-urlencoded"
+					if e.Request.Form.Reference != "" {
+						ref := prog.References[e.Request.Form.Reference]
+						if ref.Schema != nil && ref.Schema.HasFile() {
+							e.Request.FormContentType = "multipart/form-data"
+						}
+					}
+				}
 			}
 			if err != nil {
 				return nil, i, fmt.Errorf("could not parse %v params: %v", h[1], err)
@@ -281,25 +363,72 @@ func parseComment(prog *Program, comment, pkgPath, filePath string) ([]*Endpoint
 			continue
 		}
 
+		// Default params: {none}
+		if dp := reDefaultParams.FindStringSubmatch(line); dp != nil {
+			pastDesc = true
+			if strings.TrimSpace(dp[1]) != "{none}" {
+				return nil, i, fmt.Errorf("invalid Default params: %q; must be {none}", dp[1])
+			}
+			e.NoDefaultParams = true
+			continue
+		}
+
 		// Request body:
 		// Request body (application/json):
 		req := reRequestHeader.FindStringSubmatch(line)
 		if req != nil {
 			pastDesc = true
-			if e.Request.Body != nil {
-				return nil, i, fmt.Errorf("Request Body already present")
-			}
 
-			e.Request.ContentType = prog.Config.DefaultRequestCt
+			ct := prog.Config.DefaultRequestCt
 			if req[2] != "" {
-				e.Request.ContentType = req[2]
+				ct = req[2]
+			}
+			if _, ok := e.Request.Bodies[ct]; ok {
+				return nil, i, fmt.Errorf("Request Body already present for %v", ct)
 			}
 
-			e.Request.Body, err = parseRefValue(prog, "req", req[3], filePath)
+			body, err := parseRefValue(prog, "req", req[3], filePath)
 			if err != nil {
 				return nil, i, fmt.Errorf("could not parse request params: %v", err)
 			}
 
+			if e.Request.Bodies == nil {
+				e.Request.Bodies = make(map[string]*Ref)
+			}
+			e.Request.Bodies[ct] = body
+
+			continue
+		}
+
+		// Request example: {"json": "value"}
+		// Request example: @testdata/file.json
+		if ex := reRequestExample.FindStringSubmatch(line); ex != nil {
+			pastDesc = true
+			if reqExample != nil {
+				return nil, i, fmt.Errorf("Request example already present")
+			}
+			reqExample, err = parseExample(ex[1], filePath)
+			if err != nil {
+				return nil, i, fmt.Errorf("could not parse request example: %v", err)
+			}
+			continue
+		}
+
+		// Response 200 example: {"json": "value"}
+		// Response example: @testdata/file.json
+		if ex := reRespExample.FindStringSubmatch(line); ex != nil {
+			pastDesc = true
+			code, err := ParseResponseCode(ex[2])
+			if err != nil {
+				return nil, i, err
+			}
+			if _, ok := respExamples[code]; ok {
+				return nil, i, fmt.Errorf("response %v example defined more than once", code)
+			}
+			respExamples[code], err = parseExample(ex[3], filePath)
+			if err != nil {
+				return nil, i, fmt.Errorf("could not parse response %v example: %v", code, err)
+			}
 			continue
 		}
 
@@ -313,15 +442,28 @@ func parseComment(prog *Program, comment, pkgPath, filePath string) ([]*Endpoint
 		if resp != nil {
 			pastDesc = true
 			if e.Responses == nil {
-				e.Responses = make(map[int]Response)
+				e.Responses = make(map[string]Response)
 			}
 
-			if _, ok := e.Responses[code]; ok {
-				return nil, i, fmt.Errorf("%v: response code %v defined more than once",
-					e.Path, code)
+			// Same response code with a different Content-Type.
+			if r, ok := e.Responses[code]; ok {
+				for ct, b := range resp.Bodies {
+					if _, ok := r.Bodies[ct]; ok {
+						return nil, i, fmt.Errorf("%v: response code %v defined more than once for %v",
+							e.Path, code, ct)
+					}
+					r.Bodies[ct] = b
+				}
+				if r.Description == "" {
+					r.Description = resp.Description
+				}
+				e.Responses[code] = r
+				lastResp = code
+				continue
 			}
 
 			e.Responses[code] = *resp
+			lastResp = code
 			continue
 		}
 
@@ -335,10 +477,36 @@ func parseComment(prog *Program, comment, pkgPath, filePath string) ([]*Endpoint
 		return nil, 0, fmt.Errorf("%v: must have at least one response", e.Path)
 	}
 
+	if reqExample != nil {
+		b, ok := e.Request.Bodies[MainContentType(e.Request.Bodies)]
+		if !ok {
+			return nil, 0, fmt.Errorf("%v: Request example without a Request body", e.Path)
+		}
+		b.Example = reqExample
+	}
+	for code, ex := range respExamples {
+		r, ok := e.Responses[code]
+		if !ok {
+			return nil, 0, fmt.Errorf("%v: example for response %v, but there is no such response",
+				e.Path, code)
+		}
+		b := r.Bodies[MainContentType(r.Bodies)]
+		if b.Empty {
+			return nil, 0, fmt.Errorf("%v: example for response %v, but it has no body",
+				e.Path, code)
+		}
+		b.Example = ex
+	}
+
+	if err := checkRanges(e); err != nil {
+		return nil, 0, err
+	}
+
 	if len(prog.Config.AddDefaultResponse) > 0 {
 		for _, c := range prog.Config.AddDefaultResponse {
+			// Don't add if a range on the endpoint already documents it.
 			_, ok := e.Responses[c]
-			if !ok {
+			if !ok && !hasRange(e, c) {
 				e.Responses[c] = prog.Config.DefaultResponse[c]
 			}
 		}
@@ -355,6 +523,42 @@ func parseComment(prog *Program, comment, pkgPath, filePath string) ([]*Endpoint
 	return r, 0, nil
 }
 
+// MainContentType gets the Content-Type for outputs that can only use one body,
+// and for examples (which are JSON): the first JSON Content-Type, or the first
+// Content-Type if there is no JSON one.
+func MainContentType(bodies map[string]*Ref) string {
+	ct := ContentTypes(bodies)
+	for _, c := range ct {
+		if strings.Contains(c, "json") {
+			return c
+		}
+	}
+	if len(ct) == 0 {
+		return ""
+	}
+	return ct[0]
+}
+
+// Parse an example value, which is either inline JSON or a path to a JSON file
+// relative to the Go file prefixed with @.
+func parseExample(value, filePath string) (json.RawMessage, error) {
+	value = strings.TrimSpace(value)
+
+	ex := []byte(value)
+	if strings.HasPrefix(value, "@") {
+		var err error
+		ex, err = ioutil.ReadFile(filepath.Join(filepath.Dir(filePath), value[1:]))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !json.Valid(ex) {
+		return nil, fmt.Errorf("invalid JSON: %q", value)
+	}
+	return json.RawMessage(ex), nil
+}
+
 var reParams = regexp.MustCompile(`{\w+}`)
 
 // PathParams returns all {..} delimited path parameters.
@@ -374,56 +578,194 @@ func PathParams(path string) []string {
 // ParseResponse parses a Response line.
 //
 // Exported so it can be used in the config, too.
-func ParseResponse(prog *Program, filePath, line string) (int, *Response, error) {
+func ParseResponse(prog *Program, filePath, line string) (string, *Response, error) {
 	resp := reResponseHeader.FindStringSubmatch(line)
 	if resp == nil {
-		return 0, nil, nil
+		return "", nil, nil
 	}
 
-	code := int64(http.StatusOK)
-	if resp[1] != "" {
-		var err error
-		code, err = strconv.ParseInt(strings.TrimSpace(resp[1]), 10, 32)
-		if err != nil {
-			return 0, nil, fmt.Errorf("invalid status code %#v: %v",
-				resp[1], err)
-		}
+	code, err := ParseResponseCode(resp[2])
+	if err != nil {
+		return "", nil, err
 	}
 
-	r := Response{ContentType: prog.Config.DefaultResponseCt}
+	ct := prog.Config.DefaultResponseCt
 	if resp[4] != "" {
-		r.ContentType = resp[4]
+		ct = resp[4]
 	}
 
-	var err error
-	r.Body, err = parseRefValue(prog, "resp", resp[5], filePath)
+	// Anything after the reference is the description:
+	//   Response 404: {empty} Bike not found.
+	var r Response
+	value := strings.TrimSpace(resp[5])
+	if i := strings.IndexAny(value, " \t"); i > -1 {
+		value, r.Description = value[:i], strings.TrimSpace(value[i:])
+	}
+
+	body, err := parseRefValue(prog, "resp", value, filePath)
 	if err != nil {
-		return 0, nil, fmt.Errorf("could not parse response %v params: %v", code, err)
+		return "", nil, fmt.Errorf("could not parse response %v params: %v", code, err)
 	}
+	r.Bodies = map[string]*Ref{ct: body}
 
-	codeText := fmt.Sprintf("%d %s", code, http.StatusText(int(code)))
-	switch r.Body.Description {
+	codeText := strings.TrimSpace(code + " " + StatusText(code))
+	if code == "default" {
+		codeText = "Default response"
+	}
+	switch body.Description {
 	case "":
-		r.Body.Description = codeText
+		body.Description = codeText
 	case refEmpty:
-		r.Body.Description = codeText + " (no data)"
+		body.Description = codeText + " (no data)"
+		body.Empty = true
 	case refData:
 		if resp[4] == "" {
-			return 0, nil, fmt.Errorf("explicit Content-Type required for {data} in %v: %q",
+			return "", nil, fmt.Errorf("explicit Content-Type required for {data} in %v: %q",
 				filePath, line)
 		}
 
-		r.Body.Description = fmt.Sprintf("%s (%s data)", codeText, r.ContentType)
+		body.Description = fmt.Sprintf("%s (%s data)", codeText, ct)
 	case refDefault:
 		// Make sure it's defined.
-		if _, ok := prog.Config.DefaultResponse[int(code)]; !ok {
-			return 0, nil, fmt.Errorf("no default response for %v in %v: %q",
+		dr, ok := prog.Config.DefaultResponse[code]
+		if !ok {
+			return "", nil, fmt.Errorf("no default response for %v in %v: %q",
 				code, filePath, line)
 		}
-		r.Body.Description = codeText
+
+		// Copy the references here, as the default may be different per
+		// package. Use all Content-Types of the default response, unless one
+		// is given explicitly.
+		r.Bodies = make(map[string]*Ref)
+		for _, dct := range ContentTypes(dr.Bodies) {
+			b := &Ref{Description: codeText, Reference: dr.Bodies[dct].Reference, Empty: dr.Bodies[dct].Empty}
+			if resp[4] == "" {
+				r.Bodies[dct] = b
+			} else if len(r.Bodies) == 0 {
+				r.Bodies[ct] = b
+			}
+		}
+		if len(r.Bodies) == 0 {
+			r.Bodies[ct] = &Ref{Description: codeText}
+		}
+	}
+
+	return code, &r, nil
+}
+
+// ParseResponseCode parses a response code: a HTTP status code ("404"), a range
+// of status codes ("4XX"), or "default" for all codes that aren't documented.
+// An empty string is 200.
+func ParseResponseCode(code string) (string, error) {
+	switch {
+	case code == "":
+		return "200", nil
+	case code == "default":
+		return code, nil
+	case len(code) == 3 && strings.HasSuffix(code, "XX"):
+		if code[0] < '1' || code[0] > '5' {
+			return "", fmt.Errorf("invalid status code range %#v: must be 1XX to 5XX", code)
+		}
+		return code, nil
+	default:
+		n, err := strconv.ParseInt(code, 10, 32)
+		if err != nil {
+			return "", fmt.Errorf("invalid status code %#v: %v", code, err)
+		}
+		return strconv.FormatInt(n, 10), nil
+	}
+}
+
+// ParseDefaultParam parses a parameter that's added to every endpoint, as
+// "query:pkg.Type" or "header:pkg.Type". This returns the location and the
+// reference.
+func ParseDefaultParam(prog *Program, filePath, value string) (string, *Ref, error) {
+	in, lookup, ok := strings.Cut(value, ":")
+	if !ok || lookup == "" {
+		return "", nil, fmt.Errorf("malformed default param %q: must be as query:pkg.Type or header:pkg.Type", value)
+	}
+	if in != ctxQuery && in != ctxHeader {
+		return "", nil, fmt.Errorf("invalid location %q for default param %q: must be query or header", in, lookup)
+	}
+
+	ref, err := GetReference(prog, in, false, lookup, filePath)
+	if err != nil {
+		return "", nil, fmt.Errorf("default param %q: %v", lookup, err)
+	}
+	return in, &Ref{Reference: ref.Lookup}, nil
+}
+
+var rangeText = map[byte]string{
+	'1': "Informational",
+	'2': "Success",
+	'3': "Redirection",
+	'4': "Client Error",
+	'5': "Server Error",
+}
+
+// StatusText gets the text for a response code, or a blank string if it's
+// unknown or "default".
+func StatusText(code string) string {
+	switch {
+	case len(code) == 3 && strings.HasSuffix(code, "XX"):
+		return rangeText[code[0]]
+	default:
+		n, _ := strconv.Atoi(code)
+		return http.StatusText(n)
+	}
+}
+
+// An explicit code overrides the range it's in (e.g. 404 in 4XX), so it can
+// have a different body or description. It's an error if it's returned with
+// different Content-Types than the range, as clients will pick the decoder
+// from the range.
+func checkRanges(e *Endpoint) error {
+	codes := make([]string, 0, len(e.Responses))
+	for code := range e.Responses {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	for _, code := range codes {
+		for _, rng := range codes {
+			if !inRange(code, rng) {
+				continue
+			}
+			if !sameContentTypes(e.Responses[code].Bodies, e.Responses[rng].Bodies) {
+				return fmt.Errorf("%v: response %v has different Content-Types than %v",
+					e.Path, code, rng)
+			}
+		}
+	}
+	return nil
+}
+
+func sameContentTypes(a, b map[string]*Ref) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for ct := range a {
+		if _, ok := b[ct]; !ok {
+			return false
+		}
 	}
+	return true
+}
+
+// hasRange reports if the endpoint has a response range for the code.
+func hasRange(e *Endpoint, code string) bool {
+	for rng := range e.Responses {
+		if inRange(code, rng) {
+			return true
+		}
+	}
+	return false
+}
 
-	return int(code), &r, nil
+// inRange reports if the status code is in the range, e.g. 404 in 4XX.
+func inRange(code, rng string) bool {
+	return len(rng) == 3 && strings.HasSuffix(rng, "XX") &&
+		len(code) == 3 && code[0] == rng[0] && code != rng
 }
 
 var allMethods = []string{http.MethodGet, http.MethodHead, http.MethodPost,
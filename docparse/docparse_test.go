@@ -1,6 +1,7 @@
 package docparse
 
 import (
+	"encoding/json"
 	"fmt"
 	"reflect"
 	"testing"
@@ -10,9 +11,8 @@ import (
 )
 
 func TestParseComments(t *testing.T) {
-	stdResp := map[int]Response{200: Response{
-		ContentType: "application/json",
-		Body:        &Ref{Description: "200 OK (no data)"},
+	stdResp := map[string]Response{"200": Response{
+		Bodies: map[string]*Ref{"application/json": {Description: "200 OK (no data)", Empty: true}},
 	}}
 
 	tests := []struct {
@@ -33,8 +33,7 @@ Response 200: {empty}
 				Path:    "/path",
 				Tagline: "The tagline!",
 				Request: Request{
-					ContentType: "foo",
-					Body:        &Ref{Reference: "mail.Address"},
+					Bodies: map[string]*Ref{"foo": {Reference: "mail.Address"}},
 				},
 			}},
 		},
@@ -51,8 +50,7 @@ Response 200: {empty}
 				Method: "POST",
 				Path:   "/path",
 				Request: Request{
-					ContentType: "foo",
-					Body:        &Ref{Reference: "mail.Address"},
+					Bodies: map[string]*Ref{"foo": {Reference: "mail.Address"}},
 				},
 			}, {
 				Method: "GET",
@@ -74,8 +72,7 @@ Response 200: {empty}
 				Path:    "/path",
 				Tagline: "The tagline!",
 				Request: Request{
-					ContentType: "foo",
-					Body:        &Ref{Reference: "mail.Address"},
+					Bodies: map[string]*Ref{"foo": {Reference: "mail.Address"}},
 				},
 			}, {
 				Method: "GET",
@@ -100,8 +97,7 @@ Response 200: {empty}
 				Tagline: "The tagline!",
 				Info:    "Some desc!",
 				Request: Request{
-					ContentType: "foo",
-					Body:        &Ref{Reference: "mail.Address"},
+					Bodies: map[string]*Ref{"foo": {Reference: "mail.Address"}},
 				},
 			}, {
 				Method: "GET",
@@ -123,8 +119,7 @@ Response 200: {empty}
 				Path:   "/path",
 				Info:   "A description.",
 				Request: Request{
-					ContentType: "foo",
-					Body:        &Ref{Reference: "mail.Address"},
+					Bodies: map[string]*Ref{"foo": {Reference: "mail.Address"}},
 				},
 			}},
 		},
@@ -144,8 +139,7 @@ Response 200: {empty}
 				Path:   "/path",
 				Info:   "A description.\nOf multiple lines.",
 				Request: Request{
-					ContentType: "foo",
-					Body:        &Ref{Reference: "mail.Address"},
+					Bodies: map[string]*Ref{"foo": {Reference: "mail.Address"}},
 				},
 			}},
 		},
@@ -169,8 +163,7 @@ Response 200: {empty}
 				Path:   "/path",
 				Info:   "A description.\nOf multiple lines.\n\nWith some more.\n\nAnd some more.",
 				Request: Request{
-					ContentType: "foo",
-					Body:        &Ref{Reference: "mail.Address"},
+					Bodies: map[string]*Ref{"foo": {Reference: "mail.Address"}},
 				},
 			}},
 		},
@@ -191,8 +184,7 @@ Response 200: {empty}
 				Tagline: "The tagline!",
 				Info:    "A description.",
 				Request: Request{
-					ContentType: "foo",
-					Body:        &Ref{Reference: "mail.Address"},
+					Bodies: map[string]*Ref{"foo": {Reference: "mail.Address"}},
 				},
 			}},
 		},
@@ -214,8 +206,7 @@ Response 200: {empty}
 				Tagline: "The tagline!",
 				Info:    "A description.\nOf multiple lines.",
 				Request: Request{
-					ContentType: "foo",
-					Body:        &Ref{Reference: "mail.Address"},
+					Bodies: map[string]*Ref{"foo": {Reference: "mail.Address"}},
 				},
 			}},
 		},
@@ -231,8 +222,7 @@ Response 200: {empty}
 				Method: "POST",
 				Path:   "/path",
 				Request: Request{
-					ContentType: "application/json",
-					Body:        &Ref{Reference: "mail.Address"},
+					Bodies: map[string]*Ref{"application/json": {Reference: "mail.Address"}},
 				}},
 			}},
 
@@ -262,8 +252,7 @@ Response 200: {empty}
 				Method: "POST",
 				Path:   "/path",
 				Request: Request{
-					ContentType: "foo",
-					Body:        &Ref{Reference: "mail.Address"},
+					Bodies: map[string]*Ref{"foo": {Reference: "mail.Address"}},
 				},
 			}},
 		},
@@ -278,25 +267,201 @@ Response 400 (w00t): {empty}
 			[]*Endpoint{{
 				Method: "POST",
 				Path:   "/path",
-				Responses: map[int]Response{
-					200: {
-						ContentType: "application/json",
-						Body:        &Ref{Description: "200 OK (no data)"},
+				Responses: map[string]Response{
+					"200": {
+						Bodies: map[string]*Ref{"application/json": {Description: "200 OK (no data)", Empty: true}},
 					},
-					400: {
-						ContentType: "w00t",
-						Body:        &Ref{Description: "400 Bad Request (no data)"},
+					"400": {
+						Bodies: map[string]*Ref{"w00t": {Description: "400 Bad Request (no data)", Empty: true}},
 					},
 				},
 			}},
 		},
 
-		//{"err-double-code", `
-		//		POST /path
+		{"form-file", `
+POST /path
+
+Form: testForm
+Response 200: {empty}
+		`,
+			"",
+			[]*Endpoint{{
+				Method: "POST",
+				Path:   "/path",
+				Request: Request{
+					Form:            &Ref{Reference: "docparse.testForm"},
+					FormContentType: "multipart/form-data",
+				}},
+			}},
+
+		{"examples", `
+POST /path
+
+Request body: net/mail.Address
+Request example: {"name": "Martin", "address": "martin@example.com"}
+Response 200: net/mail.Address
+Response 200 example: @testdata/example/address.json
+		`,
+			"",
+			[]*Endpoint{{
+				Method: "POST",
+				Path:   "/path",
+				Request: Request{
+					Bodies: map[string]*Ref{"application/json": {
+						Reference: "mail.Address",
+						Example:   json.RawMessage(`{"name": "Martin", "address": "martin@example.com"}`),
+					}},
+				},
+				Responses: map[string]Response{"200": {
+					Bodies: map[string]*Ref{"application/json": {
+						Description: "200 OK",
+						Reference:   "mail.Address",
+						Example:     json.RawMessage("{\n    \"name\": \"Martin\",\n    \"email\": \"martin@example.com\"\n}\n"),
+					}},
+				}},
+			}},
+		},
+		{"response-description", `
+POST /path
+
+Response 200: {empty}
+	Bike was
+	updated.
+Response 404: {empty} Bike
+	not found.
+		`,
+			"",
+			[]*Endpoint{{
+				Method: "POST",
+				Path:   "/path",
+				Responses: map[string]Response{
+					"200": {
+						Bodies:      map[string]*Ref{"application/json": {Description: "200 OK (no data)", Empty: true}},
+						Description: "Bike was updated.",
+					},
+					"404": {
+						Bodies:      map[string]*Ref{"application/json": {Description: "404 Not Found (no data)", Empty: true}},
+						Description: "Bike not found.",
+					},
+				},
+			}},
+		},
+		{"content-types", `
+POST /path
 
-		//		Response 200: {empty}
-		//		Response 200: {empty}
-		//	`, "response", nil},
+Request body (application/json): net/mail.Address
+Request body (application/xml): net/mail.Address
+Response 200 (text/csv): {data}
+Response 200 (application/json): net/mail.Address
+		`,
+			"",
+			[]*Endpoint{{
+				Method: "POST",
+				Path:   "/path",
+				Request: Request{
+					Bodies: map[string]*Ref{
+						"application/json": {Reference: "mail.Address"},
+						"application/xml":  {Reference: "mail.Address"},
+					},
+				},
+				Responses: map[string]Response{
+					"200": {
+						Bodies: map[string]*Ref{
+							"text/csv":         {Description: "200 OK (text/csv data)"},
+							"application/json": {Reference: "mail.Address", Description: "200 OK"},
+						},
+					},
+				},
+			}},
+		},
+		{"err-double-code", `
+POST /path
+
+Response 200: {empty}
+Response 200 (application/json): {empty}
+		`, "response code 200 defined more than once for application/json", nil},
+		{"err-double-request", `
+POST /path
+
+Request body: net/mail.Address
+Request body (application/json): net/mail.Address
+Response 200: {empty}
+		`, "Request Body already present for application/json", nil},
+		{"ranges", `
+POST /path
+
+Response 200: {empty}
+Response 404: {empty} Not found.
+Response 4XX: net/mail.Address
+Response default: net/mail.Address
+		`,
+			"",
+			[]*Endpoint{{
+				Method: "POST",
+				Path:   "/path",
+				Responses: map[string]Response{
+					"200": {
+						Bodies: map[string]*Ref{"application/json": {Description: "200 OK (no data)", Empty: true}},
+					},
+					"404": {
+						Bodies:      map[string]*Ref{"application/json": {Description: "404 Not Found (no data)", Empty: true}},
+						Description: "Not found.",
+					},
+					"4XX": {
+						Bodies: map[string]*Ref{"application/json": {Reference: "mail.Address", Description: "4XX Client Error"}},
+					},
+					"default": {
+						Bodies: map[string]*Ref{"application/json": {Reference: "mail.Address", Description: "Default response"}},
+					},
+				},
+			}},
+		},
+		{"err-range-conflict", `
+POST /path
+
+Response 404 (text/plain): {data}
+Response 4XX: net/mail.Address
+		`, "response 404 has different Content-Types than 4XX", nil},
+		{"err-example-json", `
+POST /path
+
+Response 200: {empty}
+Response example: {"name": "Martin"
+		`, "invalid JSON", nil},
+		{"err-example-no-response", `
+POST /path
+
+Response 200: {empty}
+Response 400 example: {"error": "oh noes"}
+		`, "no such response", nil},
+		{"err-example-empty", `
+POST /path
+
+Response 200: {empty}
+Response 200 example: {"error": "oh noes"}
+		`, "example for response 200, but it has no body", nil},
+		{"err-example-no-body", `
+POST /path
+
+Request example: {"name": "Martin"}
+Response 200: {empty}
+		`, "without a Request body", nil},
+		{"default-params-none", `
+GET /path
+
+Default params: {none}
+Response 200: {empty}
+		`, "", []*Endpoint{{
+			Method:          "GET",
+			Path:            "/path",
+			NoDefaultParams: true,
+		}}},
+		{"err-default-params", `
+GET /path
+
+Default params: header
+Response 200: {empty}
+		`, "must be {none}", nil},
 	}
 
 	for _, tt := range tests {
@@ -556,25 +721,64 @@ func str(t any) string {
 func TestParseResponse(t *testing.T) {
 	tests := []struct {
 		in       string
-		wantCode int
+		wantCode string
 		wantResp *Response
 		wantErr  string
 	}{
 		{
 			"Response 400: net/mail.Address",
-			400,
+			"400",
 			&Response{
-				ContentType: "application/json",
-				Body:        &Ref{Reference: "mail.Address", Description: "400 Bad Request"},
+				Bodies: map[string]*Ref{"application/json": {Reference: "mail.Address", Description: "400 Bad Request"}},
 			},
 			"",
 		},
 		{
 			"Response 400 net/mail.Address",
-			0,
+			"",
 			nil,
 			"",
 		},
+		{
+			"Response 404: net/mail.Address  Bike not found.",
+			"404",
+			&Response{
+				Bodies:      map[string]*Ref{"application/json": {Reference: "mail.Address", Description: "404 Not Found"}},
+				Description: "Bike not found.",
+			},
+			"",
+		},
+		{
+			"Response 404: {empty} Bike not found.",
+			"404",
+			&Response{
+				Bodies:      map[string]*Ref{"application/json": {Description: "404 Not Found (no data)", Empty: true}},
+				Description: "Bike not found.",
+			},
+			"",
+		},
+		{
+			"Response 5XX: net/mail.Address",
+			"5XX",
+			&Response{
+				Bodies: map[string]*Ref{"application/json": {Reference: "mail.Address", Description: "5XX Server Error"}},
+			},
+			"",
+		},
+		{
+			"Response default: net/mail.Address",
+			"default",
+			&Response{
+				Bodies: map[string]*Ref{"application/json": {Reference: "mail.Address", Description: "Default response"}},
+			},
+			"",
+		},
+		{
+			"Response 6XX: net/mail.Address",
+			"",
+			nil,
+			"invalid status code range",
+		},
 	}
 
 	for i, tt := range tests {
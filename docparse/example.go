@@ -0,0 +1,99 @@
+package docparse
+
+import "strings"
+
+// Example values for string formats.
+var exampleFormats = map[string]string{
+	"date-time":    "2006-01-02T15:04:05Z",
+	"date":         "2006-01-02",
+	"time":         "15:04:05",
+	"email":        "user@example.com",
+	"idn-email":    "user@example.com",
+	"hostname":     "example.com",
+	"idn-hostname": "example.com",
+	"uri":          "https://example.com",
+	"uuid":         "3fa85f64-5717-4562-b3fc-2c963f66afa6",
+}
+
+// ExampleFor generates an example value for the schema, which can be encoded as
+// JSON.
+//
+// Explicit {example: ..} values are used if present, and enums, defaults,
+// ranges, and formats are used to make it look realistic. References are
+// resolved from prog.References; recursive references are set to nil.
+func ExampleFor(prog *Program, schema *Schema) any {
+	return exampleFor(prog, schema, make(map[string]struct{}))
+}
+
+func exampleFor(prog *Program, schema *Schema, seen map[string]struct{}) any {
+	if schema == nil || schema.OmitDoc {
+		return nil
+	}
+	if schema.Example != nil {
+		return schema.Example
+	}
+
+	if schema.Reference != "" {
+		lookup := strings.TrimPrefix(schema.Reference, "#/definitions/")
+		if _, ok := seen[lookup]; ok {
+			return nil
+		}
+		ref, ok := prog.References[lookup]
+		if !ok {
+			return nil
+		}
+
+		seen[lookup] = struct{}{}
+		defer delete(seen, lookup)
+		return exampleFor(prog, ref.Schema, seen)
+	}
+
+	if len(schema.Enum) > 0 {
+		if schema.Type == "array" {
+			return []any{schema.Enum[0]}
+		}
+		return schema.Enum[0]
+	}
+	if schema.Default != nil {
+		return schema.Default
+	}
+
+	switch schema.Type {
+	case "object":
+		obj := make(map[string]any, len(schema.Properties))
+		for name, p := range schema.Properties {
+			if p.OmitDoc {
+				continue
+			}
+			obj[name] = exampleFor(prog, p, seen)
+		}
+		if schema.AdditionalProperties != nil {
+			obj["key"] = exampleFor(prog, schema.AdditionalProperties, seen)
+		}
+		return obj
+	case "array":
+		if schema.Items == nil {
+			return []any{}
+		}
+		return []any{exampleFor(prog, schema.Items, seen)}
+	case "integer":
+		if schema.Minimum != 0 || schema.Maximum != 0 {
+			return schema.Minimum
+		}
+		return 1
+	case "number":
+		if schema.Minimum != 0 || schema.Maximum != 0 {
+			return float64(schema.Minimum)
+		}
+		return 1.5
+	case "boolean":
+		return true
+	case "string":
+		if f, ok := exampleFormats[schema.Format]; ok {
+			return f
+		}
+		return "string"
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,51 @@
+package docparse
+
+import (
+	"testing"
+
+	"zgo.at/zstd/ztest"
+)
+
+func TestExampleFor(t *testing.T) {
+	prog := NewProgram(false)
+	prog.References["pkg.node"] = Reference{Schema: &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"name":     {Type: "string"},
+			"parent":   {Reference: "pkg.node"},
+			"children": {Type: "array", Items: &Schema{Reference: "#/definitions/pkg.node"}},
+		},
+	}}
+
+	tests := []struct {
+		name string
+		in   *Schema
+		want string
+	}{
+		{"nil", nil, `null`},
+		{"string", &Schema{Type: "string"}, `"string"`},
+		{"format", &Schema{Type: "string", Format: "date-time"}, `"2006-01-02T15:04:05Z"`},
+		{"enum", &Schema{Type: "string", Enum: []any{"asc", "desc"}}, `"asc"`},
+		{"default", &Schema{Type: "integer", Default: int64(20)}, `20`},
+		{"range", &Schema{Type: "integer", Minimum: 5, Maximum: 10}, `5`},
+		{"example", &Schema{Type: "integer", Example: int64(42)}, `42`},
+		{"array", &Schema{Type: "array", Items: &Schema{Type: "boolean"}}, `[true]`},
+		{"object", &Schema{Type: "object", Properties: map[string]*Schema{
+			"a":    {Type: "number"},
+			"b":    {Type: "string", Format: "email"},
+			"omit": {Type: "string", OmitDoc: true},
+		}}, `{"a": 1.5, "b": "user@example.com"}`},
+		{"recursive", &Schema{Reference: "pkg.node"},
+			`{"children": [null], "name": "string", "parent": null}`},
+		{"unknown-ref", &Schema{Reference: "pkg.nope"}, `null`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := ExampleFor(prog, tt.in)
+			if d := ztest.Diff(str(out), tt.want, ztest.DiffJSON); d != "" {
+				t.Error(d)
+			}
+		})
+	}
+}
@@ -38,6 +38,12 @@ func FindComments(w io.Writer, prog *Program) error {
 				continue
 			}
 
+			pkgProg, defaults, err := packageConfig(prog, pkg)
+			if err != nil {
+				allErr = append(allErr, err)
+				continue
+			}
+
 			for fullPath, f := range pkg.Files {
 				// Print as just <pkgname>/<file> in errors instead of full path.
 				relPath := fullPath
@@ -51,7 +57,7 @@ func FindComments(w io.Writer, prog *Program) error {
 				}
 
 				for _, c := range f.Comments {
-					e, relLine, err := parseComment(prog, c.Text(), p.ImportPath, fullPath)
+					e, relLine, err := parseComment(pkgProg, c.Text(), p.ImportPath, fullPath)
 					if err != nil {
 						p := fset.Position(c.Pos())
 						allErr = append(allErr, fmt.Errorf("%v:%v %v",
@@ -73,6 +79,9 @@ func FindComments(w io.Writer, prog *Program) error {
 						e[i+1].Method = a.Method
 						e[i+1].Tags = a.Tags
 					}
+					for i := range e {
+						defaults.apply(e[i])
+					}
 
 					prog.Endpoints = append(prog.Endpoints, e...)
 				}
@@ -96,6 +105,10 @@ func FindComments(w io.Writer, prog *Program) error {
 		return key(prog.Endpoints[i]) < key(prog.Endpoints[j])
 	})
 
+	if prog.Config.SplitReadWrite {
+		splitReadWrite(prog)
+	}
+
 	// It's probably better to call this per package or file, rather than once
 	// for everything (much more memory-efficient for large packages). OTOH,
 	// perhaps this is "good enough"?
@@ -106,10 +119,23 @@ func FindComments(w io.Writer, prog *Program) error {
 type declCache struct {
 	ts   *ast.TypeSpec
 	vs   *ast.ValueSpec
+	fd   *ast.FuncDecl
 	file string
 }
 
-var declsCache = make(map[string][]declCache)
+var (
+	declsCache = make(map[string][]declCache)
+	declsFset  = token.NewFileSet() // FileSet for everything in declsCache.
+)
+
+// fieldPos gets the position of a field as "file:line".
+func fieldPos(file string, f *ast.Field) string {
+	p := declsFset.Position(f.Pos())
+	if !p.IsValid() {
+		return file
+	}
+	return fmt.Sprintf("%s:%d", file, p.Line)
+}
 
 // findType attempts to find a type.
 //
@@ -190,6 +216,36 @@ func findValue(currentFile, pkgPath, name string) (
 		name, resolvedPath)
 }
 
+// findMethod finds the method name on the type typeName; both value and pointer
+// receivers are considered. A nil FuncDecl is returned if the type doesn't have
+// this method.
+func findMethod(currentFile, pkgPath, typeName, name string) (*ast.FuncDecl, error) {
+	dbg("findMethod: file: %#v, pkgPath: %#v, type: %#v, name: %#v",
+		currentFile, pkgPath, typeName, name)
+	resolvedPath, pkg, err := resolvePackage(currentFile, pkgPath)
+	if err != nil {
+		return nil, fmt.Errorf("could not resolve package: %v", err)
+	}
+
+	decls, err := getDecls(pkg, resolvedPath)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, decl := range decls {
+		if decl.fd == nil || decl.fd.Name.Name != name || len(decl.fd.Recv.List) == 0 {
+			continue
+		}
+
+		recv, ok := dropTypePointers(decl.fd.Recv.List[0].Type).(*ast.Ident)
+		if ok && recv.Name == typeName {
+			return decl.fd, nil
+		}
+	}
+
+	return nil, nil
+}
+
 func resolvePackage(currentFile, pkgPath string) (
 	resolvedPath string, pkg *build.Package, err error,
 ) {
@@ -220,8 +276,7 @@ func getDecls(pkg *build.Package, pkgPath string) ([]declCache, error) {
 	}
 
 	dbg("getDecls: parsing dir %#v: %#v", pkg.Dir, pkg.GoFiles)
-	fset := token.NewFileSet()
-	pkgs, err := zgo.ParseFiles(fset, pkg.Dir, pkg.GoFiles, parser.ParseComments)
+	pkgs, err := zgo.ParseFiles(declsFset, pkg.Dir, pkg.GoFiles, parser.ParseComments)
 	if err != nil {
 		return nil, fmt.Errorf("parse error: %v", err)
 	}
@@ -229,6 +284,14 @@ func getDecls(pkg *build.Package, pkgPath string) ([]declCache, error) {
 	for _, p := range pkgs {
 		for path, f := range p.Files {
 			for _, d := range f.Decls {
+				// Methods, to see if a type implements an interface.
+				if fd, ok := d.(*ast.FuncDecl); ok && fd.Recv != nil {
+					decls = append(decls, declCache{
+						fd: fd, file: path,
+					})
+					continue
+				}
+
 				// Only need to cache *ast.GenDecl with what we're interested in.
 				if gd, ok := d.(*ast.GenDecl); ok {
 					for _, s := range gd.Specs {
@@ -307,7 +370,8 @@ func GetReference(prog *Program, context string, isEmbed bool, lookup, filePath
 	if ref, ok := prog.References[lookup]; ok {
 		// Update context: some structs are embedded but also referenced
 		// directly.
-		if ref.IsEmbed {
+		if ref.IsEmbed && !isEmbed {
+			ref.IsEmbed = false
 			prog.References[lookup] = ref
 		}
 		return &ref, nil
@@ -319,6 +383,15 @@ func GetReference(prog *Program, context string, isEmbed bool, lookup, filePath
 		return nil, err
 	}
 
+	// Use hand-written schema file instead of the struct fields.
+	sf, err := schemaFile(prog, filepath.Base(pkg)+"."+name, ts, foundPath)
+	if err != nil {
+		return nil, err
+	}
+	if sf != "" {
+		return schemaFileReference(prog, context, isEmbed, name, pkg, foundPath, sf, ts)
+	}
+
 	var st *ast.StructType
 	switch typ := ts.Type.(type) {
 	case *ast.StructType:
@@ -349,7 +422,7 @@ func GetReference(prog *Program, context string, isEmbed bool, lookup, filePath
 
 	var tagName string
 	switch ref.Context {
-	case ctxPath, ctxQuery, ctxForm:
+	case ctxPath, ctxQuery, ctxForm, ctxHeader:
 		tagName = ref.Context
 	case ctxReq, ctxResp:
 		tagName = prog.Config.StructTag
@@ -373,12 +446,14 @@ func GetReference(prog *Program, context string, isEmbed bool, lookup, filePath
 				continue
 			}
 
+			// Embedded structs with a tag are a field that references the
+			// struct, so it needs its own definition.
 			switch t := f.Type.(type) {
 			case *ast.Ident:
 				err = resolveType(prog, context, false, t, "", pkg)
 			case *ast.StarExpr:
 				ex, _ := t.X.(*ast.Ident)
-				err = resolveType(prog, context, true, ex, "", pkg)
+				err = resolveType(prog, context, false, ex, "", pkg)
 			}
 
 			if err != nil {
@@ -494,7 +569,52 @@ func GetReference(prog *Program, context string, isEmbed bool, lookup, filePath
 	return &ref, nil
 }
 
+// schemaFileReference adds a reference with the schema from a hand-written JSON
+// schema file.
+func schemaFileReference(
+	prog *Program, context string, isEmbed bool,
+	name, pkg, filePath, schemaPath string, ts *ast.TypeSpec,
+) (*Reference, error) {
+	schema, err := readSchemaFile(schemaPath)
+	if err != nil {
+		return nil, err
+	}
+
+	ref := Reference{
+		Name:    name,
+		Package: pkg,
+		Lookup:  filepath.Base(pkg) + "." + name,
+		File:    filePath,
+		Context: context,
+		IsEmbed: isEmbed,
+		Schema:  schema,
+	}
+	if ts.Doc != nil {
+		ref.Info, _ = parseTags(strings.TrimSpace(ts.Doc.Text()))
+	}
+	if schema.Title == "" {
+		schema.Title = name
+	}
+	if schema.Description == "" {
+		schema.Description = ref.Info
+	}
+
+	// Store before resolving to prevent cyclic lookup issues.
+	prog.References[ref.Lookup] = ref
+	err = resolveSchemaReferences(prog, context, schema, filePath)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", schemaPath, err)
+	}
+
+	return &ref, nil
+}
+
 func findNested(prog *Program, context string, isEmbed bool, f *ast.Field, filePath, pkg string) (string, error) {
+	// Added as a file upload; don't need to look at the struct.
+	if context == ctxForm && isFileHeader(f.Type) {
+		return "", nil
+	}
+
 	var name *ast.Ident
 
 	sw := f.Type
@@ -594,6 +714,13 @@ start:
 	if x, _ := MapType(prog, lookup); x != "" {
 		return lookup, nil
 	}
+	x, err := marshalerType(filePath, pkg, name.Name)
+	if err != nil {
+		return "", err
+	}
+	if x != "" {
+		return lookup, nil
+	}
 
 	if _, ok := prog.References[lookup]; !ok {
 		err := resolveType(prog, context, isEmbed, name, filePath, pkg)
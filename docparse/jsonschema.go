@@ -6,9 +6,13 @@ import (
 	"go/ast"
 	"io/ioutil"
 	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 
+	"gopkg.in/yaml.v3"
 	"zgo.at/kommentaar/zgo"
 	"zgo.at/zstd/zstring"
 )
@@ -19,13 +23,32 @@ type Schema struct {
 	Title       string   `json:"title,omitempty"`
 	Description string   `json:"description,omitempty"`
 	Type        string   `json:"type,omitempty"`
-	Enum        []string `json:"enum,omitempty"`
+	Enum        []any    `json:"enum,omitempty"`
 	Format      string   `json:"format,omitempty"`
 	Required    []string `json:"required,omitempty"`
-	Default     string   `json:"default,omitempty"`
+	Default     any      `json:"default,omitempty"`
 	Minimum     int      `json:"minimum,omitempty"`
 	Maximum     int      `json:"maximum,omitempty"`
 	Readonly    *bool    `json:"readOnly,omitempty"`
+	Writeonly   *bool    `json:"-"` // Not supported in OpenAPI 2.
+	Nullable    bool     `json:"x-nullable,omitempty"`
+	Example     any      `json:"example,omitempty"`
+
+	// Not set by docparse, but can be used in schema files.
+	MultipleOf       float64 `json:"multipleOf,omitempty"`
+	ExclusiveMinimum bool    `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum bool    `json:"exclusiveMaximum,omitempty"`
+	MinLength        int     `json:"minLength,omitempty"`
+	MaxLength        int     `json:"maxLength,omitempty"`
+	Pattern          string  `json:"pattern,omitempty"`
+	MinItems         int     `json:"minItems,omitempty"`
+	MaxItems         int     `json:"maxItems,omitempty"`
+	UniqueItems      bool    `json:"uniqueItems,omitempty"`
+	MinProperties    int     `json:"minProperties,omitempty"`
+	MaxProperties    int     `json:"maxProperties,omitempty"`
+	Discriminator    string  `json:"discriminator,omitempty"`
+	XML              any     `json:"xml,omitempty"`
+	ExternalDocs     any     `json:"externalDocs,omitempty"`
 
 	// Store array items; for primitives:
 	//   "items": {"type": "string"}
@@ -36,6 +59,10 @@ type Schema struct {
 	// Store structs.
 	Properties map[string]*Schema `json:"properties,omitempty"`
 
+	// Only set from schema files; outputs can also use this to wrap a $ref,
+	// as nothing else is allowed next to it.
+	AllOf []*Schema `json:"allOf,omitempty"`
+
 	// Order of the properties in the struct; contains the key of the Properties
 	// field.
 	PropertyOrder []string `json:"-"`
@@ -45,6 +72,10 @@ type Schema struct {
 	AdditionalProperties *Schema `json:"additionalProperties,omitempty"`
 
 	OmitDoc bool `json:"-"` // {omitdoc}
+
+	// How arrays are serialized in path, query, and form parameters: csv,
+	// ssv, tsv, pipes, or multi. {collection: ..}
+	CollectionFormat string `json:"-"`
 }
 
 // Convert a struct to a JSON schema.
@@ -75,16 +106,26 @@ func structToSchema(prog *Program, name, tagName string, ref Reference) (*Schema
 			return nil, fmt.Errorf("cannot parse %v: %v", ref.Lookup, err)
 		}
 
-		if !zstring.Contains([]string{"path", "query", "form"}, ref.Context) {
-			fixRequired(schema, prop)
-		}
-
 		if prop == nil {
 			return nil, fmt.Errorf(
 				"structToSchema: prop is nil for field %#v in %#v",
 				name, ref.Lookup)
 		}
 
+		err = setValues(name, fieldPos(ref.File, p.KindField), prop)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse %v: %v", ref.Lookup, err)
+		}
+
+		if !zstring.Contains([]string{"path", "query", "form", "header"}, ref.Context) {
+			fixRequired(schema, prop)
+		} else {
+			err := setCollectionFormat(prog, ref.Context, name, prop)
+			if err != nil {
+				return nil, fmt.Errorf("cannot parse %v: %v", ref.Lookup, err)
+			}
+		}
+
 		schema.PropertyOrder = append(schema.PropertyOrder, name)
 		schema.Properties[name] = prop
 	}
@@ -92,6 +133,155 @@ func structToSchema(prog *Program, name, tagName string, ref Reference) (*Schema
 	return schema, nil
 }
 
+// CollectionFormats are the valid values for {collection: ..}.
+var CollectionFormats = []string{"csv", "ssv", "tsv", "pipes", "multi"}
+
+// Set the default collection format for arrays in path, query, and form
+// parameters, and validate that it's only used on arrays.
+func setCollectionFormat(prog *Program, context, name string, prop *Schema) error {
+	if prop.Type != "array" {
+		if prop.CollectionFormat != "" {
+			return fmt.Errorf("{collection: %s} on %q: can only be used on arrays",
+				prop.CollectionFormat, name)
+		}
+		return nil
+	}
+
+	// Arrays of file uploads are sent as multiple form fields.
+	if prop.Items != nil && prop.Items.Type == typeFile {
+		return nil
+	}
+
+	// multi can't be used in paths, so don't apply a config default of multi
+	// to every path parameter.
+	if prop.CollectionFormat == "" && !(context == ctxPath && prog.Config.CollectionFormat == "multi") {
+		prop.CollectionFormat = prog.Config.CollectionFormat
+	}
+	if prop.CollectionFormat == "multi" && context == ctxPath {
+		return fmt.Errorf("{collection: multi} on %q: can't be used for path parameters", name)
+	}
+	return nil
+}
+
+// Convert the {default: ..}, {enum: ..}, and {example: ..} values to the type
+// of the property, so that it's output as 5 rather than "5" for integers.
+// Arrays are space-separated.
+func setValues(name, pos string, prop *Schema) error {
+	if ex, ok := prop.Example.(string); ok {
+		v, err := typedValue(prop, ex)
+		if err != nil {
+			return fmt.Errorf("%s: {example: %s} on %q: %v", pos, ex, name, err)
+		}
+		prop.Example = v
+	}
+
+	if def, ok := prop.Default.(string); ok {
+		v, err := typedValue(prop, def)
+		if err == nil {
+			err = checkRange(prop, v)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: {default: %s} on %q: %v", pos, def, name, err)
+		}
+		prop.Default = v
+	}
+
+	// Enums of an array apply to the items.
+	typ := prop.Type
+	if typ == "array" && prop.Items != nil {
+		typ = prop.Items.Type
+	}
+	for i, e := range prop.Enum {
+		str, ok := e.(string)
+		if !ok {
+			continue
+		}
+		v, err := typedScalar(typ, str)
+		if err == nil {
+			err = checkRange(prop, v)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: {enum: ..} on %q: %v", pos, name, err)
+		}
+		prop.Enum[i] = v
+	}
+
+	return nil
+}
+
+func typedValue(prop *Schema, v string) (any, error) {
+	if prop.Type != "array" {
+		return typedScalar(prop.Type, v)
+	}
+	if prop.Items == nil {
+		return v, nil
+	}
+
+	list := []any{}
+	for _, e := range strings.Fields(v) {
+		t, err := typedScalar(prop.Items.Type, e)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, t)
+	}
+	return list, nil
+}
+
+func typedScalar(typ, v string) (any, error) {
+	switch typ {
+	case "integer":
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("not an integer: %q", v)
+		}
+		return n, nil
+	case "number":
+		n, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, fmt.Errorf("not a number: %q", v)
+		}
+		return n, nil
+	case "boolean":
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("not a boolean: %q", v)
+		}
+		return b, nil
+	default:
+		return v, nil
+	}
+}
+
+// Make sure that numeric values are inside the {range: ..}.
+func checkRange(prop *Schema, v any) error {
+	if prop.Minimum == 0 && prop.Maximum == 0 {
+		return nil
+	}
+
+	var n float64
+	switch vv := v.(type) {
+	case []any:
+		for _, e := range vv {
+			if err := checkRange(prop, e); err != nil {
+				return err
+			}
+		}
+		return nil
+	case int64:
+		n = float64(vv)
+	case float64:
+		n = vv
+	default:
+		return nil
+	}
+
+	if (prop.Minimum != 0 && n < float64(prop.Minimum)) || (prop.Maximum != 0 && n > float64(prop.Maximum)) {
+		return fmt.Errorf("%v is outside the range %d-%d", v, prop.Minimum, prop.Maximum)
+	}
+	return nil
+}
+
 // The required tags are added to the property itself, rather than to the
 // parent. So fix that by moving it from "prop" to "parent".
 //
@@ -110,7 +300,10 @@ const (
 	paramOptional  = "optional"
 	paramOmitEmpty = "omitempty"
 	paramReadOnly  = "readonly"
+	paramWriteOnly = "writeonly"
 	paramOmitDoc   = "omitdoc"
+	paramFile      = "file"
+	paramNullable  = "nullable"
 )
 
 func setTags(name, fName string, p *Schema, tags []string) error {
@@ -130,6 +323,13 @@ func setTags(name, fName string, p *Schema, tags []string) error {
 		case paramReadOnly:
 			t := true
 			p.Readonly = &t
+		case paramWriteOnly:
+			t := true
+			p.Writeonly = &t
+		case paramFile:
+			p.Type = typeFile
+		case paramNullable:
+			p.Nullable = true
 
 		// Various string formats.
 		// https://tools.ietf.org/html/draft-handrews-json-schema-validation-01#section-7.3
@@ -154,16 +354,28 @@ func setTags(name, fName string, p *Schema, tags []string) error {
 			switch {
 			case strings.HasPrefix(t, "enum: "):
 				p.Type = "enum"
-				for _, e := range strings.Split(strings.ReplaceAll(t, "\n", " "), " ") {
+				for _, e := range strings.Split(strings.ReplaceAll(t[5:], "\n", " "), " ") {
 					e = strings.TrimSpace(e)
 					if e != "" {
 						p.Enum = append(p.Enum, e)
 					}
 				}
 
+			case strings.HasPrefix(t, "collection: "):
+				p.CollectionFormat = strings.TrimSpace(t[11:])
+				if !zstring.Contains(CollectionFormats, p.CollectionFormat) {
+					return fmt.Errorf("invalid collection format for %#v: %#v; must be one of %s",
+						name, p.CollectionFormat, strings.Join(CollectionFormats, ", "))
+				}
+
 			case strings.HasPrefix(t, "default: "):
 				p.Default = strings.TrimSpace(t[8:])
 
+			case strings.HasPrefix(t, "example: "):
+				// Converted to the correct type in setValues(), as we don't
+				// know the type yet.
+				p.Example = strings.TrimSpace(t[8:])
+
 			case strings.HasPrefix(t, "range: "):
 				rng := strings.Split(t[6:], "-")
 				if len(rng) != 2 {
@@ -228,6 +440,22 @@ func fieldToSchema(prog *Program, fName, tagName string, ref Reference, f *ast.F
 		}
 	}
 
+	// File uploads from {file} or *multipart.FileHeader.
+	if p.Type == typeFile || isFileHeader(f.Type) {
+		if ref.Context != ctxForm {
+			if p.Type == typeFile {
+				return nil, fmt.Errorf("{file} can only be used on form parameters: %s", fName)
+			}
+		} else {
+			p.Type = typeFile
+			if arr, ok := f.Type.(*ast.ArrayType); ok && !isByteSlice(arr) {
+				p.Type = "array"
+				p.Items = &Schema{Type: typeFile}
+			}
+			return &p, nil
+		}
+	}
+
 	pkg := ref.Package
 	var name *ast.Ident
 
@@ -261,31 +489,50 @@ start:
 			name = typ
 		}
 
-	// Pointer type; we don't really care about this for now, so just read over
-	// it.
+	// Pointer type; these can be null in request and response bodies.
 	case *ast.StarExpr:
+		if ref.Context == ctxReq || ref.Context == ctxResp {
+			p.Nullable = true
+		}
 		sw = typ.X
 		goto start
 
 	// Simple identifiers such as "string", "int", "MyType", etc.
 	case *ast.Ident:
 		mappedType, mappedFormat := MapType(prog, pkg+"."+typ.Name)
-		if mappedType == "" {
-			// Only check for canonicalType if this isn't mapped.
-			canon, err := canonicalType(ref.File, pkg, typ)
+		if mappedType == "" && !zgo.PredeclaredType(typ.Name) {
+			mappedType, err = marshalerType(ref.File, pkg, typ.Name)
 			if err != nil {
-				return nil, fmt.Errorf("cannot get canonical type: %v", err)
-			}
-			if canon != nil {
-				sw = canon
-				goto start
+				return nil, err
 			}
 		}
 		if mappedType != "" {
-			p.Type = JSONSchemaType(mappedType)
-		} else {
-			p.Type = JSONSchemaType(typ.Name)
+			err := setMappedType(prog, ref, &p, mappedType, mappedFormat)
+			if err != nil {
+				return nil, err
+			}
+			setNullType(ref, &p, pkg, typ.Name)
+			return &p, nil
+		}
+
+		if sref, err := schemaFileRef(prog, ref, pkg, typ.Name); err != nil {
+			return nil, err
+		} else if sref != "" {
+			p.Description = "" // SwaggerHub will complain if both Description and $ref are set.
+			p.Reference = sref
+			return &p, nil
+		}
+
+		// Only check for canonicalType if this isn't mapped.
+		canon, err := canonicalType(ref.File, pkg, typ)
+		if err != nil {
+			return nil, fmt.Errorf("cannot get canonical type: %v", err)
 		}
+		if canon != nil {
+			sw = canon
+			goto start
+		}
+		p.Type = JSONSchemaType(typ.Name)
 		if mappedFormat != "" {
 			p.Format = mappedFormat
 		}
@@ -308,6 +555,10 @@ start:
 			if err != nil {
 				return nil, fmt.Errorf("anon struct: %v", err)
 			}
+			err = setValues(propName, fieldPos(ref.File, f), prop)
+			if err != nil {
+				return nil, fmt.Errorf("anon struct: %v", err)
+			}
 
 			p.Properties[propName] = prop
 			p.PropertyOrder = append(p.PropertyOrder, propName)
@@ -326,23 +577,39 @@ start:
 		lookup := pkg + "." + name.Name
 		t, f := MapType(prog, lookup)
 		if t == "" {
-			// Only check for canonicalType if this isn't mapped.
-			canon, err := canonicalType(ref.File, pkgSel.Name, typ.Sel)
+			t, err = marshalerType(ref.File, pkg, name.Name)
 			if err != nil {
-				return nil, fmt.Errorf("cannot get canonical type: %v", err)
+				return nil, err
 			}
-			if canon != nil {
-				sw = canon
-				goto start
+		}
+		if t != "" {
+			err := setMappedType(prog, ref, &p, t, f)
+			if err != nil {
+				return nil, err
 			}
+			setNullType(ref, &p, pkg, name.Name)
+			return &p, nil
 		}
 
-		p.Format = f
-		if t != "" {
-			p.Type = JSONSchemaType(t)
+		if sref, err := schemaFileRef(prog, ref, pkg, name.Name); err != nil {
+			return nil, err
+		} else if sref != "" {
+			p.Description = "" // SwaggerHub will complain if both Description and $ref are set.
+			p.Reference = sref
 			return &p, nil
 		}
 
+		// Only check for canonicalType if this isn't mapped.
+		canon, err := canonicalType(ref.File, pkgSel.Name, typ.Sel)
+		if err != nil {
+			return nil, fmt.Errorf("cannot get canonical type: %v", err)
+		}
+		if canon != nil {
+			sw = canon
+			goto start
+		}
+		p.Format = f
+
 		// Deal with array.
 		// TODO: don't do this inline but at the end. Reason it doesn't work not
 		// is because we always use GetReference().
@@ -374,9 +641,9 @@ start:
 			dbg("ERR FOUND MapType: %s", err.Error())
 			return &p, nil
 		}
-		if isPrimitive(vtyp.Name) {
+		if t := JSONSchemaType(vtyp.Name); isPrimitive(t) {
 			// we are done, no need for a lookup of a custom type
-			p.AdditionalProperties = &Schema{Type: JSONSchemaType(vtyp.Name)}
+			p.AdditionalProperties = &Schema{Type: t}
 			return &p, nil
 		}
 
@@ -398,6 +665,16 @@ start:
 	case *ast.ArrayType:
 		p.Type = "array"
 
+		// Anonymous struct items.
+		if _, ok := dropTypePointers(typ.Elt).(*ast.StructType); ok {
+			items, err := fieldToSchema(prog, fName, tagName, ref, &ast.Field{Type: typ.Elt})
+			if err != nil {
+				return nil, err
+			}
+			p.Items = items
+			return &p, nil
+		}
+
 		err := resolveArray(prog, ref, pkg, &p, typ.Elt)
 		if err != nil {
 			return nil, err
@@ -436,6 +713,40 @@ start:
 	return &p, nil
 }
 
+// typeFile is used for file uploads in form parameters. This isn't a JSON
+// schema type, but is supported by OpenAPI.
+const typeFile = "file"
+
+// isFileHeader reports if the type is a *multipart.FileHeader or a slice of
+// them.
+func isFileHeader(typ ast.Expr) bool {
+	if arr, ok := typ.(*ast.ArrayType); ok {
+		typ = arr.Elt
+	}
+
+	sel, ok := dropTypePointers(typ).(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	pkg, ok := sel.X.(*ast.Ident)
+	return ok && pkg.Name == "multipart" && sel.Sel.Name == "FileHeader"
+}
+
+func isByteSlice(arr *ast.ArrayType) bool {
+	ident, ok := arr.Elt.(*ast.Ident)
+	return ok && ident.Name == "byte"
+}
+
+// HasFile reports if the schema has any file upload properties.
+func (s Schema) HasFile() bool {
+	for _, p := range s.Properties {
+		if p.Type == typeFile || (p.Items != nil && p.Items.Type == typeFile) {
+			return true
+		}
+	}
+	return false
+}
+
 func dropTypePointers(typ ast.Expr) ast.Expr {
 	var t *ast.StarExpr
 	var ok bool
@@ -484,6 +795,15 @@ func lookupTypeAndRef(file, pkg, name string) (string, string, error) {
 func resolveArray(prog *Program, ref Reference, pkg string, p *Schema, typ ast.Expr) error {
 	asw := typ
 
+	// Pointer items can be null in request and response bodies.
+	if _, ok := typ.(*ast.StarExpr); ok && (ref.Context == ctxReq || ref.Context == ctxResp) {
+		defer func() {
+			if p.Items != nil {
+				p.Items.Nullable = true
+			}
+		}()
+	}
+
 	var name *ast.Ident
 
 arrayStart:
@@ -534,8 +854,33 @@ arrayStart:
 		return fmt.Errorf("fieldToSchema: unknown array type: %T", typ)
 	}
 
-	// Check if the type resolves to a Go primitive.
 	lookup := pkg + "." + name.Name
+	mapped, format := MapType(prog, lookup)
+	if mapped == "" {
+		var err error
+		mapped, err = marshalerType(ref.File, pkg, name.Name)
+		if err != nil {
+			return err
+		}
+	}
+	if mapped != "" {
+		p.Items = &Schema{}
+		err := setMappedType(prog, ref, p.Items, mapped, format)
+		if err != nil {
+			return err
+		}
+		setNullType(ref, p.Items, pkg, name.Name)
+		return nil
+	}
+
+	if sref, err := schemaFileRef(prog, ref, pkg, name.Name); err != nil {
+		return err
+	} else if sref != "" {
+		p.Items = &Schema{Reference: sref}
+		return nil
+	}
+
+	// Check if the type resolves to a Go primitive.
 	t, err := getTypeInfo(prog, lookup, ref.File)
 	if err != nil {
 		return err
@@ -641,6 +986,308 @@ func canonicalType(currentFile, pkgPath string, typ *ast.Ident) (ast.Expr, error
 	return ts.Type, nil
 }
 
+// marshalerType gets the JSON schema type for types that implement
+// encoding.TextMarshaler or json.Marshaler.
+//
+// A MarshalJSON() method can document the type it produces with {type: ..} in
+// the method's comment, which takes precedence. Types with a MarshalText()
+// method are always a string.
+//
+// An empty string is returned if the type implements neither.
+func marshalerType(currentFile, pkgPath, name string) (string, error) {
+	if zgo.PredeclaredType(name) {
+		return "", nil
+	}
+
+	mj, err := findMethod(currentFile, pkgPath, name, "MarshalJSON")
+	if err != nil {
+		return "", fmt.Errorf("marshalerType: %v", err)
+	}
+	if mj != nil && mj.Doc != nil {
+		_, tags := parseTags(mj.Doc.Text())
+		for _, t := range tags {
+			if !strings.HasPrefix(t, "type: ") {
+				continue
+			}
+
+			typ := JSONSchemaType(strings.TrimSpace(t[5:]))
+			if !isPrimitive(typ) {
+				return "", fmt.Errorf("%s.%s.MarshalJSON: invalid type %q; must be a primitive",
+					pkgPath, name, typ)
+			}
+			return typ, nil
+		}
+	}
+
+	mt, err := findMethod(currentFile, pkgPath, name, "MarshalText")
+	if err != nil {
+		return "", fmt.Errorf("marshalerType: %v", err)
+	}
+	if mt != nil {
+		return "string", nil
+	}
+	return "", nil
+}
+
+// setMappedType sets the schema for a mapped type on p. The mapping can be a Go
+// predeclared type, an inline JSON schema, or a reference to a struct.
+func setMappedType(prog *Program, ref Reference, p *Schema, mapped, format string) error {
+	switch {
+	case zgo.PredeclaredType(mapped) || isPrimitive(mapped):
+		p.Type = JSONSchemaType(mapped)
+
+	case strings.HasPrefix(mapped, "{"):
+		var s Schema
+		err := json.Unmarshal([]byte(mapped), &s)
+		if err != nil {
+			return fmt.Errorf("invalid JSON schema %q: %v", mapped, err)
+		}
+
+		// Anything set from the field's comment takes precedence.
+		p.Type = ""
+		overlaySchema(&s, p)
+		*p = s
+
+	default:
+		mref, err := GetReference(prog, ref.Context, false, mapped, ref.File)
+		if err != nil {
+			return fmt.Errorf("mapped type %q: %v", mapped, err)
+		}
+		p.Description = "" // SwaggerHub will complain if both Description and $ref are set.
+		p.Reference = mref.Lookup
+	}
+
+	if format != "" {
+		p.Format = format
+	}
+	return nil
+}
+
+// setNullType sets Nullable for mapped types that can be null in request and
+// response bodies: the Null* types from database/sql and all types from
+// github.com/guregu/null.
+func setNullType(ref Reference, p *Schema, pkg, name string) {
+	if ref.Context != ctxReq && ref.Context != ctxResp {
+		return
+	}
+	if ref.File != "" {
+		if imp, err := zgo.ResolveImport(ref.File, pkg); err == nil && imp != "" {
+			pkg = imp
+		}
+	}
+	if (pkg == "database/sql" && strings.HasPrefix(name, "Null")) || reGureguNull.MatchString(pkg) {
+		p.Nullable = true
+	}
+}
+
+// The github.com/guregu/null package is also published as
+// gopkg.in/guregu/null.v4.
+var reGureguNull = regexp.MustCompile(`^(github\.com/guregu/null(/v\d+)?|gopkg\.in/guregu/null\.v\d+)$`)
+
+// overlaySchema sets all non-zero fields from src on dst.
+func overlaySchema(dst, src *Schema) {
+	d, s := reflect.ValueOf(dst).Elem(), reflect.ValueOf(src).Elem()
+	for i := 0; i < s.NumField(); i++ {
+		if !s.Field(i).IsZero() {
+			d.Field(i).Set(s.Field(i))
+		}
+	}
+}
+
+// schemaFile gets the path to a hand-written JSON schema file for a type from
+// either the map-schemas setting or a {schema: ..} property in the type's
+// documentation. An empty string is returned if there is no schema file.
+//
+// Paths in the documentation are relative to the directory of the Go file.
+func schemaFile(prog *Program, lookup string, ts *ast.TypeSpec, filePath string) (string, error) {
+	if p, ok := prog.Config.MapSchemas[lookup]; ok {
+		return p, nil
+	}
+	if ts.Doc == nil || !strings.Contains(ts.Doc.Text(), "{schema:") {
+		return "", nil
+	}
+
+	_, tags := parseTags(ts.Doc.Text())
+	for _, t := range tags {
+		if !strings.HasPrefix(t, "schema: ") {
+			continue
+		}
+		p := strings.TrimSpace(t[7:])
+		if p == "" {
+			return "", fmt.Errorf("%s: {schema: ..} has no path", lookup)
+		}
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(filepath.Dir(filePath), p)
+		}
+		return p, nil
+	}
+	return "", nil
+}
+
+// schemaFileRef adds the type to prog.References and returns the lookup if it
+// has a schema file. An empty string is returned if it doesn't.
+func schemaFileRef(prog *Program, ref Reference, pkg, name string) (string, error) {
+	if zgo.PredeclaredType(name) {
+		return "", nil
+	}
+
+	ts, foundPath, impPath, err := findType(ref.File, pkg, name)
+	if err != nil {
+		dbg("schemaFileRef: %v", err)
+		return "", nil
+	}
+	sf, err := schemaFile(prog, filepath.Base(impPath)+"."+name, ts, foundPath)
+	if err != nil || sf == "" {
+		return "", err
+	}
+
+	sref, err := GetReference(prog, ref.Context, false, pkg+"."+name, ref.File)
+	if err != nil {
+		return "", err
+	}
+	return sref.Lookup, nil
+}
+
+// readSchemaFile reads a JSON schema from a JSON or YAML file.
+//
+// It's an error if the file uses keywords that aren't in the OpenAPI 2 Schema
+// Object, as they would be silently dropped from the output.
+func readSchemaFile(path string) (*Schema, error) {
+	var raw map[string]any
+	err := readAndUnmarshalSchemaFile(path, &raw)
+	if err != nil {
+		return nil, err
+	}
+
+	delete(raw, "$schema")
+	err = checkSchemaKeywords(raw, "")
+	if err != nil {
+		return nil, fmt.Errorf("%q: %v", path, err)
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%q: %v", path, err)
+	}
+	var schema Schema
+	err = json.Unmarshal(data, &schema)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshal schema: %q: %v", path, err)
+	}
+
+	setPropertyOrder(&schema)
+	return &schema, nil
+}
+
+// schemaKeywords lists the keywords of the OpenAPI 2 Schema Object; JSON
+// schema keywords such as oneOf or const aren't supported in OpenAPI 2.
+//
+// https://github.com/OAI/OpenAPI-Specification/blob/main/versions/2.0.md#schema-object
+var schemaKeywords = map[string]struct{}{
+	"$ref": {}, "format": {}, "title": {}, "description": {}, "default": {},
+	"multipleOf": {}, "maximum": {}, "exclusiveMaximum": {}, "minimum": {},
+	"exclusiveMinimum": {}, "maxLength": {}, "minLength": {}, "pattern": {},
+	"maxItems": {}, "minItems": {}, "uniqueItems": {}, "maxProperties": {},
+	"minProperties": {}, "required": {}, "enum": {}, "type": {}, "items": {},
+	"allOf": {}, "properties": {}, "additionalProperties": {},
+	"discriminator": {}, "readOnly": {}, "xml": {}, "externalDocs": {},
+	"example": {}, "x-nullable": {},
+}
+
+func checkSchemaKeywords(schema map[string]any, path string) error {
+	for k, v := range schema {
+		if _, ok := schemaKeywords[k]; !ok {
+			return fmt.Errorf("keyword %q at %q is not an OpenAPI 2 Schema Object keyword", k, "/"+path)
+		}
+
+		var err error
+		switch k {
+		case "items", "additionalProperties":
+			if m, ok := v.(map[string]any); ok {
+				err = checkSchemaKeywords(m, path+k+"/")
+			}
+		case "allOf":
+			all, _ := v.([]any)
+			for i, a := range all {
+				if m, ok := a.(map[string]any); ok {
+					err = checkSchemaKeywords(m, path+k+"/"+strconv.Itoa(i)+"/")
+					if err != nil {
+						break
+					}
+				}
+			}
+		case "properties":
+			props, _ := v.(map[string]any)
+			for name, p := range props {
+				if m, ok := p.(map[string]any); ok {
+					err = checkSchemaKeywords(m, path+"properties/"+name+"/")
+					if err != nil {
+						break
+					}
+				}
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setPropertyOrder sets PropertyOrder to the sorted property names, as the
+// order in the file is lost.
+func setPropertyOrder(schema *Schema) {
+	if schema == nil {
+		return
+	}
+
+	schema.PropertyOrder = make([]string, 0, len(schema.Properties))
+	for k, p := range schema.Properties {
+		schema.PropertyOrder = append(schema.PropertyOrder, k)
+		setPropertyOrder(p)
+	}
+	sort.Strings(schema.PropertyOrder)
+	for _, a := range schema.AllOf {
+		setPropertyOrder(a)
+	}
+	setPropertyOrder(schema.Items)
+	setPropertyOrder(schema.AdditionalProperties)
+}
+
+// resolveSchemaReferences adds all $ref types in schema to prog.References.
+// References starting with "#" are kept as-is.
+func resolveSchemaReferences(prog *Program, context string, schema *Schema, filePath string) error {
+	if schema == nil {
+		return nil
+	}
+
+	if schema.Reference != "" && !strings.HasPrefix(schema.Reference, "#") {
+		ref, err := GetReference(prog, context, false, schema.Reference, filePath)
+		if err != nil {
+			return err
+		}
+		schema.Reference = ref.Lookup
+	}
+
+	for _, p := range schema.Properties {
+		err := resolveSchemaReferences(prog, context, p, filePath)
+		if err != nil {
+			return err
+		}
+	}
+	for _, a := range schema.AllOf {
+		err := resolveSchemaReferences(prog, context, a, filePath)
+		if err != nil {
+			return err
+		}
+	}
+	err := resolveSchemaReferences(prog, context, schema.Items, filePath)
+	if err != nil {
+		return err
+	}
+	return resolveSchemaReferences(prog, context, schema.AdditionalProperties, filePath)
+}
+
 func readAndUnmarshalSchemaFile(path string, target any) error {
 	data, err := ioutil.ReadFile(path)
 	if err != nil {
@@ -653,6 +1300,8 @@ func readAndUnmarshalSchemaFile(path string, target any) error {
 		return fmt.Errorf("unknown file type: %q", path)
 	case ".json":
 		f = json.Unmarshal
+	case ".yaml", ".yml":
+		f = yaml.Unmarshal
 	}
 	if err := f(data, target); err != nil {
 		return fmt.Errorf("unmarshal schema: %q: %v", path, err)
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"go/ast"
 	"os"
+	"reflect"
 	"testing"
 
 	"zgo.at/zstd/ztest"
@@ -31,7 +32,7 @@ func TestFieldToProperty(t *testing.T) {
 		"deeper":    {Reference: "a.refAnother"},
 		"docs": {Type: "string", Description: "This has some documentation!",
 			Required: []string{"docs"},
-			Enum:     []string{"one", "two", "three"},
+			Enum:     []any{"one", "two", "three"},
 		},
 	}
 
@@ -103,3 +104,295 @@ func TestFieldToProperty(t *testing.T) {
 		}
 	})
 }
+
+func TestMarshalerType(t *testing.T) {
+	prog := NewProgram(false)
+
+	ref, err := GetReference(prog, "req", false, "testMarshalers", ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]*Schema{
+		"Text":   {Type: "string"},
+		"Texts":  {Type: "array", Items: &Schema{Type: "string"}},
+		"Amount": {Type: "integer", Nullable: true},
+	}
+	if d := ztest.Diff(str(want), str(ref.Schema.Properties)); d != "" {
+		t.Error(d)
+	}
+	if _, ok := prog.References["docparse.testText"]; ok {
+		t.Error("testText added to references")
+	}
+}
+
+func TestNullable(t *testing.T) {
+	prog := NewProgram(false)
+	prog.Config.MapTypes["sql.NullString"] = "string"
+	prog.Config.MapTypes["sql.NullInt64"] = "integer"
+
+	ref, err := GetReference(prog, "req", false, "testNullable", ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]*Schema{
+		"Str":      {Type: "string"},
+		"Ptr":      {Type: "string", Nullable: true},
+		"Explicit": {Type: "string", Nullable: true},
+		"SQLStr":   {Type: "string", Nullable: true},
+		"SQLInt":   {Type: "integer", Nullable: true},
+		"Ptrs":     {Type: "array", Items: &Schema{Type: "string", Nullable: true}},
+	}
+	if d := ztest.Diff(str(want), str(ref.Schema.Properties)); d != "" {
+		t.Error(d)
+	}
+
+	// Pointers in query parameters are just optional.
+	ref, err = GetReference(prog, "query", false, "testNullable", ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := ref.Schema.Properties["Ptr"]; p.Nullable {
+		t.Errorf("Ptr is nullable in query context: %#v", p)
+	}
+}
+
+func TestSetValues(t *testing.T) {
+	tests := []struct {
+		in      Schema
+		want    Schema
+		wantErr string
+	}{
+		{Schema{Type: "string", Example: "foo"}, Schema{Type: "string", Example: "foo"}, ""},
+		{Schema{Type: "integer", Example: "42"}, Schema{Type: "integer", Example: int64(42)}, ""},
+		{Schema{Type: "number", Example: "4.2"}, Schema{Type: "number", Example: 4.2}, ""},
+		{Schema{Type: "boolean", Example: "true"}, Schema{Type: "boolean", Example: true}, ""},
+		{Schema{Type: "array", Items: &Schema{Type: "integer"}, Example: "1 2"},
+			Schema{Type: "array", Items: &Schema{Type: "integer"}, Example: []any{int64(1), int64(2)}}, ""},
+		{Schema{Type: "integer"}, Schema{Type: "integer"}, ""},
+		{Schema{Type: "integer", Example: "x"}, Schema{}, "{example: x} on \"field\": not an integer"},
+		{Schema{Type: "array", Items: &Schema{Type: "boolean"}, Example: "true x"},
+			Schema{}, "not a boolean"},
+
+		{Schema{Type: "integer", Default: "20"}, Schema{Type: "integer", Default: int64(20)}, ""},
+		{Schema{Type: "boolean", Default: "false"}, Schema{Type: "boolean", Default: false}, ""},
+		{Schema{Type: "array", Items: &Schema{Type: "string"}, Default: "a b"},
+			Schema{Type: "array", Items: &Schema{Type: "string"}, Default: []any{"a", "b"}}, ""},
+		{Schema{Type: "integer", Default: "20", Minimum: 1, Maximum: 10},
+			Schema{}, "file.go:1: {default: 20} on \"field\": 20 is outside the range 1-10"},
+		{Schema{Type: "number", Default: "x"}, Schema{}, "not a number"},
+
+		{Schema{Type: "integer", Enum: []any{"1", "2"}}, Schema{Type: "integer", Enum: []any{int64(1), int64(2)}}, ""},
+		{Schema{Type: "array", Items: &Schema{Type: "integer"}, Enum: []any{"1"}},
+			Schema{Type: "array", Items: &Schema{Type: "integer"}, Enum: []any{int64(1)}}, ""},
+		{Schema{Type: "integer", Enum: []any{"1", "x"}}, Schema{}, "{enum: ..} on \"field\": not an integer"},
+		{Schema{Type: "integer", Enum: []any{"5", "50"}, Maximum: 10}, Schema{}, "50 is outside the range 0-10"},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			err := setValues("field", "file.go:1", &tt.in)
+			if !ztest.ErrorContains(err, tt.wantErr) {
+				t.Fatalf("wrong err\nout:  %v\nwant: %v", err, tt.wantErr)
+			}
+			if tt.wantErr != "" {
+				return
+			}
+			if !reflect.DeepEqual(tt.in, tt.want) {
+				t.Errorf("\nout:  %#v\nwant: %#v", tt.in, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetMappedType(t *testing.T) {
+	tests := []struct {
+		mapped, format string
+		in, want       Schema
+		wantErr        string
+	}{
+		{"int64", "", Schema{}, Schema{Type: "integer"}, ""},
+		{"string", "date-time", Schema{Description: "x"},
+			Schema{Type: "string", Format: "date-time", Description: "x"}, ""},
+		{`{"type": "string", "enum": ["a", "b"]}`, "", Schema{Description: "x"},
+			Schema{Type: "string", Enum: []any{"a", "b"}, Description: "x"}, ""},
+		{`{"type": "string", "enum": ["a", "b"]}`, "", Schema{Enum: []any{"c"}},
+			Schema{Type: "string", Enum: []any{"c"}}, ""},
+		{"net/mail.Address", "", Schema{Description: "x"},
+			Schema{Reference: "mail.Address"}, ""},
+		{`{"type": `, "", Schema{}, Schema{}, "invalid JSON schema"},
+		{"net/mail.Nope", "", Schema{}, Schema{}, "could not find type"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mapped, func(t *testing.T) {
+			prog := NewProgram(false)
+			err := setMappedType(prog, Reference{Context: "req", File: "."}, &tt.in, tt.mapped, tt.format)
+			if !ztest.ErrorContains(err, tt.wantErr) {
+				t.Fatalf("wrong err\nout:  %v\nwant: %v", err, tt.wantErr)
+			}
+			if tt.wantErr != "" {
+				return
+			}
+			if d := ztest.Diff(str(tt.want), str(tt.in)); d != "" {
+				t.Error(d)
+			}
+		})
+	}
+}
+
+func TestSchemaFile(t *testing.T) {
+	prog := NewProgram(false)
+	ref, err := GetReference(prog, "req", false, "testSchemaFiles", ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]*Schema{
+		"Price":   {Reference: "docparse.testMoney"},
+		"Prices":  {Type: "array", Items: &Schema{Reference: "docparse.testMoney"}},
+		"Address": {Reference: "docparse.testAddress", Nullable: true},
+	}
+	if d := ztest.Diff(str(want), str(ref.Schema.Properties)); d != "" {
+		t.Error(d)
+	}
+
+	money := prog.References["docparse.testMoney"]
+	if d := ztest.Diff(str(&Schema{
+		Title:       "testMoney",
+		Description: "Amount with exactly two decimals.",
+		Type:        "string",
+		Format:      "decimal",
+	}), str(money.Schema)); d != "" {
+		t.Error(d)
+	}
+	if money.Info != "testMoney is documented in a schema file." {
+		t.Errorf("wrong Info: %q", money.Info)
+	}
+
+	addr := prog.References["docparse.testAddress"]
+	if d := ztest.Diff(`[contact street]`, fmt.Sprint(addr.Schema.PropertyOrder)); d != "" {
+		t.Error(d)
+	}
+	if r := addr.Schema.Properties["contact"].Reference; r != "mail.Address" {
+		t.Errorf("wrong reference: %q", r)
+	}
+	if _, ok := prog.References["mail.Address"]; !ok {
+		t.Error("mail.Address not in references")
+	}
+
+	t.Run("config", func(t *testing.T) {
+		prog := NewProgram(false)
+		prog.Config.MapSchemas["mail.Address"] = "testdata/schema/money.json"
+		ref, err := GetReference(prog, "req", false, "net/mail.Address", ".")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ref.Schema.Format != "decimal" {
+			t.Errorf("wrong schema: %#v", ref.Schema)
+		}
+	})
+
+	t.Run("openapi2", func(t *testing.T) {
+		schema, err := readSchemaFile("testdata/schema/limits.yaml")
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := map[string]*Schema{
+			"code":    {Type: "string", Pattern: "^[A-Z]{3}$", MinLength: 3, MaxLength: 3},
+			"tags":    {Type: "array", Items: &Schema{Type: "string"}, UniqueItems: true, MaxItems: 10},
+			"address": {AllOf: []*Schema{{Reference: "net/mail.Address"}}, Nullable: true},
+		}
+		if d := ztest.Diff(str(want), str(schema.Properties)); d != "" {
+			t.Error(d)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := readSchemaFile("testdata/schema/invalid.json")
+		if !ztest.ErrorContains(err, `keyword "oneOf" at "/properties/id/" is not an OpenAPI 2 Schema Object keyword`) {
+			t.Errorf("wrong error: %v", err)
+		}
+	})
+}
+
+func TestFileUpload(t *testing.T) {
+	prog := NewProgram(false)
+	ref, err := GetReference(prog, "form", false, "testForm", ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]*Schema{
+		"name":    {Type: "string"},
+		"avatar":  {Type: "file"},
+		"photos":  {Type: "array", Items: &Schema{Type: "file"}},
+		"resume":  {Type: "file"},
+		"comment": {Type: "string"},
+	}
+	if d := ztest.Diff(str(want), str(ref.Schema.Properties)); d != "" {
+		t.Error(d)
+	}
+	if _, ok := prog.References["multipart.FileHeader"]; ok {
+		t.Error("multipart.FileHeader added to references")
+	}
+
+	_, err = GetReference(NewProgram(false), "req", false, "testForm", ".")
+	if !ztest.ErrorContains(err, "{file} can only be used on form parameters: Resume") {
+		t.Errorf("wrong error: %v", err)
+	}
+}
+
+func TestCollectionFormat(t *testing.T) {
+	prog := NewProgram(false)
+	prog.Config.CollectionFormat = "pipes"
+	ref, err := GetReference(prog, "query", false, "testQuery", ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{"id": "multi", "tag": "pipes", "search": ""}
+	for k, v := range want {
+		if cf := ref.Schema.Properties[k].CollectionFormat; cf != v {
+			t.Errorf("%s: got %q; want %q", k, cf, v)
+		}
+	}
+
+	tests := []struct {
+		in      string
+		wantErr string
+	}{
+		{"collection: ssv", ""},
+		{"collection: x", `invalid collection format for "f": "x"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			err := setTags("f", "", &Schema{}, []string{tt.in})
+			if !ztest.ErrorContains(err, tt.wantErr) {
+				t.Errorf("wrong error\nout:  %v\nwant: %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	err = setCollectionFormat(prog, "query", "f", &Schema{Type: "string", CollectionFormat: "csv"})
+	if !ztest.ErrorContains(err, "can only be used on arrays") {
+		t.Errorf("wrong error: %v", err)
+	}
+	err = setCollectionFormat(prog, "path", "f", &Schema{Type: "array", CollectionFormat: "multi"})
+	if !ztest.ErrorContains(err, "can't be used for path parameters") {
+		t.Errorf("wrong error: %v", err)
+	}
+
+	prog.Config.CollectionFormat = "multi"
+	for _, ctx := range []string{"path", "query"} {
+		s := &Schema{Type: "array"}
+		err = setCollectionFormat(prog, ctx, "f", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := map[string]string{"path": "", "query": "multi"}[ctx]; s.CollectionFormat != want {
+			t.Errorf("%s: got %q; want %q", ctx, s.CollectionFormat, want)
+		}
+	}
+}
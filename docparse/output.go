@@ -0,0 +1,50 @@
+package docparse
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// OutputDir gets the directory to write to for outputs that write a directory
+// rather than to a writer, creating it if it doesn't exist yet. The output
+// name is used in errors.
+func OutputDir(prog *Program, output string) (string, error) {
+	dir := prog.Config.OutputDir
+	if dir == "" {
+		return "", fmt.Errorf("%s: output-dir not set", output)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("%s: %v", output, err)
+	}
+	return dir, nil
+}
+
+// PageName gets a filename or anchor for a tag or model; anything that's not a
+// letter, number, ".", "-", or "_" is replaced with "-".
+func PageName(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '-' || r == '_' {
+			return r
+		}
+		return '-'
+	}, s)
+}
+
+// CheckPageNames returns an error if two names have the same PageName, as
+// they would overwrite each other (e.g. "a b" and "a-b").
+func CheckPageNames(output string, names []string) error {
+	names = append([]string(nil), names...)
+	sort.Strings(names)
+
+	seen := make(map[string]string, len(names))
+	for _, n := range names {
+		p := PageName(n)
+		if other, ok := seen[p]; ok && other != n {
+			return fmt.Errorf("%s: %q and %q have the same page name %q", output, other, n, p)
+		}
+		seen[p] = n
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package docparse
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"zgo.at/zstd/ztest"
+)
+
+func TestOutputDir(t *testing.T) {
+	prog := NewProgram(false)
+	_, err := OutputDir(prog, "html-site")
+	if !ztest.ErrorContains(err, "html-site: output-dir not set") {
+		t.Fatalf("wrong error: %v", err)
+	}
+
+	prog.Config.OutputDir = filepath.Join(t.TempDir(), "a", "b")
+	dir, err := OutputDir(prog, "html-site")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dir != prog.Config.OutputDir {
+		t.Errorf("wrong dir: %q", dir)
+	}
+	if _, err := os.Stat(dir); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCheckPageNames(t *testing.T) {
+	tests := []struct {
+		in      []string
+		wantErr string
+	}{
+		{[]string{"bikes", "shop", "pkg.Type-request"}, ""},
+		{[]string{"bikes", "bikes"}, ""},
+		{[]string{"a-b", "a b"}, `"a b" and "a-b" have the same page name "a-b"`},
+		{[]string{"a/b", "x", "a?b"}, `"a/b" and "a?b" have the same page name "a-b"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.in, ","), func(t *testing.T) {
+			err := CheckPageNames("html-site", tt.in)
+			if !ztest.ErrorContains(err, tt.wantErr) {
+				t.Errorf("wrong error\nout:  %v\nwant: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package docparse
+
+import (
+	"fmt"
+	"go/ast"
+	"sort"
+	"strings"
+
+	"zgo.at/zstd/zstring"
+)
+
+// Defaults for all endpoints in a package.
+type packageDefaults struct {
+	tags   []string
+	prefix string
+	auth   string
+}
+
+// Apply the package defaults to the endpoint; anything set on the endpoint
+// takes precedence.
+func (d packageDefaults) apply(e *Endpoint) {
+	if len(e.Tags) == 0 {
+		e.Tags = d.tags
+	}
+	if e.Auth == "" {
+		e.Auth = d.auth
+	}
+	e.Path = d.prefix + e.Path
+}
+
+// packageConfig reads package-wide defaults from a "kommentaar:" block in the
+// package documentation:
+//
+//	// Package bikes handles bikes.
+//	//
+//	// kommentaar:
+//	//
+//	//	tags                 bikes
+//	//	prefix               /bikes
+//	//	auth                 basic
+//	//	default-request-ct   application/json
+//	//	default-response-ct  application/json
+//	//	default-response 404: errorResponse
+//	//	add-default-response 404
+//	package bikes
+//
+// The block ends at the first blank line after it. This returns a copy of prog
+// with the package's Config, which can be used to parse the endpoints in the
+// package.
+func packageConfig(prog *Program, pkg *ast.Package) (*Program, packageDefaults, error) {
+	var d packageDefaults
+
+	// Sort for stable errors if there's more than one.
+	files := make([]string, 0, len(pkg.Files))
+	for path, f := range pkg.Files {
+		if f.Doc != nil && strings.Contains(f.Doc.Text(), "kommentaar:") {
+			files = append(files, path)
+		}
+	}
+	if len(files) == 0 {
+		return prog, d, nil
+	}
+	sort.Strings(files)
+	if len(files) > 1 {
+		return nil, d, fmt.Errorf("kommentaar: block in more than one file: %s",
+			strings.Join(files, ", "))
+	}
+
+	filePath := files[0]
+	lines := strings.Split(pkg.Files[filePath].Doc.Text(), "\n")
+	for i := range lines {
+		if strings.TrimSpace(lines[i]) == "kommentaar:" {
+			lines = lines[i+1:]
+			break
+		}
+	}
+
+	cfg := prog.Config
+	cfg.DefaultResponse = make(map[string]Response)
+	for k, v := range prog.Config.DefaultResponse {
+		cfg.DefaultResponse[k] = v
+	}
+	cfg.AddDefaultResponse = append([]string(nil), prog.Config.AddDefaultResponse...)
+	pkgProg := &Program{Config: cfg, References: prog.References}
+
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			// gofmt adds a blank line before indented blocks.
+			if i == 0 {
+				continue
+			}
+			break
+		}
+
+		key, value := line, ""
+		if i := strings.IndexAny(line, " \t"); i > -1 {
+			key, value = line[:i], strings.TrimSpace(line[i:])
+		}
+
+		switch key {
+		case "tags":
+			d.tags = strings.Fields(value)
+		case "prefix":
+			d.prefix = value
+		case "auth":
+			if !zstring.Contains([]string{"basic", "none"}, value) {
+				return nil, d, fmt.Errorf("%s: invalid auth %q; must be basic or none", filePath, value)
+			}
+			d.auth = value
+		case "default-request-ct":
+			pkgProg.Config.DefaultRequestCt = value
+		case "default-response-ct":
+			pkgProg.Config.DefaultResponseCt = value
+		case "add-default-response":
+			for _, c := range strings.Fields(value) {
+				code, err := ParseResponseCode(c)
+				if err != nil {
+					return nil, d, fmt.Errorf("%s: %v", filePath, err)
+				}
+				pkgProg.Config.AddDefaultResponse = append(pkgProg.Config.AddDefaultResponse, code)
+			}
+		case "default-response":
+			code, resp, err := ParseResponse(pkgProg, filePath, "Response "+value)
+			if err != nil {
+				return nil, d, fmt.Errorf("%s: %v", filePath, err)
+			}
+			if resp == nil {
+				return nil, d, fmt.Errorf("%s: malformed default-response: %q", filePath, value)
+			}
+			pkgProg.Config.DefaultResponse[code] = *resp
+		default:
+			return nil, d, fmt.Errorf("%s: unknown kommentaar: key %q", filePath, key)
+		}
+	}
+
+	return pkgProg, d, nil
+}
@@ -0,0 +1,49 @@
+package docparse
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"testing"
+
+	"zgo.at/zstd/ztest"
+)
+
+func TestPackageConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    packageDefaults
+		wantErr string
+	}{
+		{"none", "// Package x.\npackage x", packageDefaults{}, ""},
+		{
+			"block",
+			"// Package x.\n//\n// kommentaar:\n//\n//\ttags a b\n//\tprefix /x\n//\tauth none\n//\n//\tignored\npackage x",
+			packageDefaults{tags: []string{"a", "b"}, prefix: "/x", auth: "none"},
+			"",
+		},
+		{"invalid-auth", "// kommentaar:\n//\tauth oauth\npackage x", packageDefaults{}, `invalid auth "oauth"`},
+		{"unknown-key", "// kommentaar:\n//\tnope x\npackage x", packageDefaults{}, `unknown kommentaar: key "nope"`},
+		{"invalid-range", "// kommentaar:\n//\tadd-default-response 9XX\npackage x", packageDefaults{}, "invalid status code range"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := parser.ParseFile(token.NewFileSet(), "x.go", tt.in, parser.ParseComments)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			prog := NewProgram(false)
+			_, out, err := packageConfig(prog, &ast.Package{Name: "x", Files: map[string]*ast.File{"x.go": f}})
+			if !ztest.ErrorContains(err, tt.wantErr) {
+				t.Fatalf("wrong error\nout:  %v\nwant: %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(out, tt.want) {
+				t.Errorf("\nout:  %#v\nwant: %#v", out, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package docparse
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Server is a base URL for the API.
+type Server struct {
+	URL         string // May contain {variables}.
+	Description string
+	Variables   map[string]ServerVariable
+}
+
+// ServerVariable is a {variable} in the server URL.
+type ServerVariable struct {
+	Default string
+	Enum    []string // Allowed values; blank for any value.
+}
+
+var reServerVar = regexp.MustCompile(`^([a-zA-Z0-9_-]+)=(.+)`)
+
+// ParseServer parses a server from the configuration, as:
+//
+//	https://{region}.example.com/v1 region=eu,us Regional servers
+//
+// The first value of a variable is the default, and the list of values is
+// used as the enum if there's more than one.
+func ParseServer(line []string) (Server, error) {
+	if len(line) == 0 || line[0] == "" {
+		return Server{}, fmt.Errorf("no server URL")
+	}
+
+	s := Server{URL: line[0]}
+	rest := line[1:]
+	for len(rest) > 0 {
+		m := reServerVar.FindStringSubmatch(rest[0])
+		if m == nil {
+			break
+		}
+		rest = rest[1:]
+
+		if s.Variables == nil {
+			s.Variables = make(map[string]ServerVariable)
+		}
+		values := strings.Split(m[2], ",")
+		v := ServerVariable{Default: values[0]}
+		if len(values) > 1 {
+			v.Enum = values
+		}
+		s.Variables[m[1]] = v
+	}
+	s.Description = strings.TrimSpace(strings.Join(rest, " "))
+
+	for _, name := range PathParams(s.URL) {
+		if _, ok := s.Variables[name]; !ok {
+			return Server{}, fmt.Errorf("no value for variable %q in server URL %q", name, s.URL)
+		}
+	}
+	if _, err := url.Parse(s.Expand()); err != nil {
+		return Server{}, fmt.Errorf("invalid server URL %q: %v", s.URL, err)
+	}
+
+	return s, nil
+}
+
+// Expand the URL, replacing all {variables} with their default value.
+func (s Server) Expand() string {
+	u := s.URL
+	for name, v := range s.Variables {
+		u = strings.ReplaceAll(u, "{"+name+"}", v.Default)
+	}
+	return u
+}
+
+// AllServers gets all base URLs for the API. This is Servers, or a server for
+// every scheme from Host and Basepath if there are no Servers. This returns nil
+// if there is no Host.
+func (c Config) AllServers() []Server {
+	if len(c.Servers) > 0 {
+		return c.Servers
+	}
+	if c.Host == "" {
+		return nil
+	}
+
+	schemes := c.Schemes
+	if len(schemes) == 0 {
+		schemes = []string{"https"}
+	}
+	servers := make([]Server, 0, len(schemes))
+	for _, s := range schemes {
+		servers = append(servers, Server{URL: s + "://" + c.Host + c.Basepath})
+	}
+	return servers
+}
@@ -0,0 +1,71 @@
+package docparse
+
+import (
+	"strings"
+	"testing"
+
+	"zgo.at/zstd/ztest"
+)
+
+func TestParseServer(t *testing.T) {
+	tests := []struct {
+		in, want, wantErr string
+	}{
+		{"https://api.example.com", `{"URL": "https://api.example.com", "Description": "", "Variables": null}`, ""},
+		{"https://api.example.com/v1 Production server",
+			`{"URL": "https://api.example.com/v1", "Description": "Production server", "Variables": null}`, ""},
+		{"https://{region}.example.com region=eu,us version=v1 Regional", `{
+			"URL": "https://{region}.example.com",
+			"Description": "Regional",
+			"Variables": {
+				"region":  {"Default": "eu", "Enum": ["eu", "us"]},
+				"version": {"Default": "v1", "Enum": null}
+			}}`, ""},
+		{"https://{region}.example.com", "", `no value for variable "region"`},
+		{"", "", "no server URL"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			out, err := ParseServer(strings.Fields(tt.in))
+			if !ztest.ErrorContains(err, tt.wantErr) {
+				t.Fatalf("wrong error\nout:  %v\nwant: %v", err, tt.wantErr)
+			}
+			if tt.wantErr != "" {
+				return
+			}
+			if d := ztest.Diff(str(out), tt.want, ztest.DiffJSON); d != "" {
+				t.Error(d)
+			}
+		})
+	}
+}
+
+func TestAllServers(t *testing.T) {
+	tests := []struct {
+		name string
+		in   Config
+		want []string
+	}{
+		{"none", Config{}, nil},
+		{"host", Config{Host: "example.com", Basepath: "/v1"}, []string{"https://example.com/v1"}},
+		{"schemes", Config{Host: "example.com", Schemes: []string{"http", "https"}},
+			[]string{"http://example.com", "https://example.com"}},
+		{"servers", Config{Host: "example.com", Servers: []Server{{
+			URL:       "https://{env}.example.com",
+			Variables: map[string]ServerVariable{"env": {Default: "prod"}},
+		}}}, []string{"https://prod.example.com"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out []string
+			for _, s := range tt.in.AllServers() {
+				out = append(out, s.Expand())
+			}
+			if d := ztest.Diff(strings.Join(out, " "), strings.Join(tt.want, " ")); d != "" {
+				t.Error(d)
+			}
+		})
+	}
+}
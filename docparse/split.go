@@ -0,0 +1,260 @@
+package docparse
+
+// splitReadWrite creates separate request and response references for structs
+// with readonly or writeonly fields. Readonly fields are removed from the
+// request variant, and writeonly fields from the response variant.
+//
+// The variants are stored as "pkg.Type-request" and "pkg.Type-response". A
+// variant is only created if it's different from the original, and the
+// original is removed if nothing uses it any more.
+func splitReadWrite(prog *Program) {
+	s := splitter{prog: prog, seen: make(map[string]string)}
+
+	for _, e := range prog.Endpoints {
+		for _, b := range e.Request.Bodies {
+			if b.Reference != "" {
+				b.Reference = s.variant(b.Reference, ctxReq)
+			}
+		}
+		for _, r := range e.Responses {
+			for _, b := range r.Bodies {
+				if b.Reference != "" {
+					b.Reference = s.variant(b.Reference, ctxResp)
+				}
+			}
+		}
+	}
+	for _, r := range prog.Config.DefaultResponse {
+		for _, b := range r.Bodies {
+			if b.Reference != "" {
+				b.Reference = s.variant(b.Reference, ctxResp)
+			}
+		}
+	}
+
+	// Remove originals which are no longer used.
+	used := make(map[string]struct{})
+	for _, e := range prog.Endpoints {
+		for _, r := range []*Ref{e.Request.Path, e.Request.Query, e.Request.Form} {
+			if r != nil {
+				s.markUsed(used, r.Reference)
+			}
+		}
+		for _, b := range e.Request.Bodies {
+			s.markUsed(used, b.Reference)
+		}
+		for _, r := range e.Responses {
+			for _, b := range r.Bodies {
+				s.markUsed(used, b.Reference)
+			}
+		}
+	}
+	for _, r := range prog.Config.DefaultResponse {
+		for _, b := range r.Bodies {
+			s.markUsed(used, b.Reference)
+		}
+	}
+	for _, orig := range s.split {
+		if _, ok := used[orig]; !ok {
+			delete(prog.References, orig)
+		}
+	}
+}
+
+type splitter struct {
+	prog  *Program
+	seen  map[string]string
+	split []string
+}
+
+var variantSuffix = map[string]string{
+	ctxReq:  "-request",
+	ctxResp: "-response",
+}
+
+// variant gets the lookup for the request or response variant of a reference.
+func (s *splitter) variant(lookup, context string) string {
+	key := lookup + " " + context
+	if v, ok := s.seen[key]; ok {
+		return v
+	}
+
+	ref, ok := s.prog.References[lookup]
+	if !ok || ref.Schema == nil || !s.needsVariant(ref.Schema, context, map[string]struct{}{lookup: {}}) {
+		s.seen[key] = lookup
+		return lookup
+	}
+
+	// Register the variant before stripping, so that references to itself in
+	// cyclic types point to the variant.
+	ref.Lookup = lookup + variantSuffix[context]
+	s.seen[key] = ref.Lookup
+
+	schema := copySchema(ref.Schema)
+	s.strip(schema, context)
+
+	ref.Context = context
+	ref.IsEmbed = false
+	ref.Schema = schema
+	s.prog.References[ref.Lookup] = ref
+	s.split = append(s.split, lookup)
+	return ref.Lookup
+}
+
+// needsVariant reports if strip would change the schema: if it, or any type it
+// references, has readonly or writeonly properties for the context. visiting
+// contains the references already being checked, to stop on cyclic types.
+func (s *splitter) needsVariant(schema *Schema, context string, visiting map[string]struct{}) bool {
+	for _, p := range schema.Properties {
+		if stripProperty(p, context) || s.valueNeedsVariant(p, context, visiting) {
+			return true
+		}
+	}
+	return false
+}
+
+// valueNeedsVariant reports if the value of a property needs a variant: a
+// reference, an anonymous struct, or an array or map of those.
+func (s *splitter) valueNeedsVariant(v *Schema, context string, visiting map[string]struct{}) bool {
+	if v.Reference != "" {
+		if _, ok := visiting[v.Reference]; !ok {
+			visiting[v.Reference] = struct{}{}
+			if ref, ok := s.prog.References[v.Reference]; ok && ref.Schema != nil &&
+				s.needsVariant(ref.Schema, context, visiting) {
+				return true
+			}
+		}
+	}
+
+	// Anonymous structs.
+	if v.Properties != nil && s.needsVariant(v, context, visiting) {
+		return true
+	}
+
+	for _, sub := range []*Schema{v.Items, v.AdditionalProperties} {
+		if sub != nil && s.valueNeedsVariant(sub, context, visiting) {
+			return true
+		}
+	}
+	return false
+}
+
+func stripProperty(p *Schema, context string) bool {
+	return (context == ctxReq && p.Readonly != nil && *p.Readonly) ||
+		(context == ctxResp && p.Writeonly != nil && *p.Writeonly)
+}
+
+// strip readonly or writeonly properties from the schema, and point all
+// references to the variants. Reports if anything was changed.
+func (s *splitter) strip(schema *Schema, context string) bool {
+	changed := false
+	for name, p := range schema.Properties {
+		if stripProperty(p, context) {
+			delete(schema.Properties, name)
+			schema.PropertyOrder = removeString(schema.PropertyOrder, name)
+			schema.Required = removeString(schema.Required, name)
+			changed = true
+			continue
+		}
+		if s.stripValue(p, context) {
+			changed = true
+		}
+	}
+	return changed
+}
+
+// stripValue strips the value of a property: a reference is pointed to the
+// variant, and anonymous structs and the items of arrays and maps are stripped.
+func (s *splitter) stripValue(v *Schema, context string) bool {
+	changed := false
+	if v.Reference != "" {
+		if lookup := s.variant(v.Reference, context); lookup != v.Reference {
+			v.Reference = lookup
+			changed = true
+		}
+	}
+
+	// Anonymous structs.
+	if v.Properties != nil && s.strip(v, context) {
+		changed = true
+	}
+
+	for _, sub := range []*Schema{v.Items, v.AdditionalProperties} {
+		if sub != nil && s.stripValue(sub, context) {
+			changed = true
+		}
+	}
+	return changed
+}
+
+func (s *splitter) markUsed(used map[string]struct{}, lookup string) {
+	if lookup == "" {
+		return
+	}
+	if _, ok := used[lookup]; ok {
+		return
+	}
+	used[lookup] = struct{}{}
+
+	ref, ok := s.prog.References[lookup]
+	if !ok || ref.Schema == nil {
+		return
+	}
+	walkSchema(ref.Schema, func(p *Schema) {
+		for _, sub := range []*Schema{p, p.Items, p.AdditionalProperties} {
+			if sub != nil {
+				s.markUsed(used, sub.Reference)
+			}
+		}
+	})
+}
+
+// walkSchema calls f for all properties in the schema, recursively.
+func walkSchema(schema *Schema, f func(*Schema)) {
+	for _, p := range schema.Properties {
+		f(p)
+		walkSchema(p, f)
+	}
+	for _, sub := range append([]*Schema{schema.Items, schema.AdditionalProperties}, schema.AllOf...) {
+		if sub != nil {
+			f(sub)
+			walkSchema(sub, f)
+		}
+	}
+}
+
+// copySchema makes a deep copy of the schema.
+func copySchema(s *Schema) *Schema {
+	if s == nil {
+		return nil
+	}
+
+	c := *s
+	c.Items = copySchema(s.Items)
+	c.AdditionalProperties = copySchema(s.AdditionalProperties)
+	if s.AllOf != nil {
+		c.AllOf = make([]*Schema, len(s.AllOf))
+		for i, a := range s.AllOf {
+			c.AllOf[i] = copySchema(a)
+		}
+	}
+	c.Enum = append([]any(nil), s.Enum...)
+	c.Required = append([]string(nil), s.Required...)
+	c.PropertyOrder = append([]string(nil), s.PropertyOrder...)
+	if s.Properties != nil {
+		c.Properties = make(map[string]*Schema, len(s.Properties))
+		for k, v := range s.Properties {
+			c.Properties[k] = copySchema(v)
+		}
+	}
+	return &c
+}
+
+func removeString(list []string, s string) []string {
+	for i := range list {
+		if list[i] == s {
+			return append(list[:i], list[i+1:]...)
+		}
+	}
+	return list
+}
@@ -0,0 +1,127 @@
+package docparse
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+
+	"zgo.at/zstd/ztest"
+)
+
+func TestSplitReadWrite(t *testing.T) {
+	prog := NewProgram(false)
+	for _, l := range []string{"testUserWrap", "net/mail.Address"} {
+		_, err := GetReference(prog, "req", false, l, ".")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	prog.Endpoints = []*Endpoint{{
+		Method: "POST",
+		Path:   "/user",
+		Request: Request{
+			Bodies: map[string]*Ref{"application/json": {Reference: "docparse.testUserWrap"}},
+		},
+		Responses: map[string]Response{
+			"200": {Bodies: map[string]*Ref{"application/json": {Reference: "docparse.testUserWrap"}}},
+			"400": {Bodies: map[string]*Ref{"application/json": {Reference: "mail.Address"}}},
+		},
+	}}
+	splitReadWrite(prog)
+
+	var refs []string
+	for k := range prog.References {
+		refs = append(refs, k)
+	}
+	sort.Strings(refs)
+	want := "[docparse.testUser-request docparse.testUser-response " +
+		"docparse.testUserWrap-request docparse.testUserWrap-response mail.Address]"
+	if d := ztest.Diff(fmt.Sprint(refs), want); d != "" {
+		t.Error(d)
+	}
+
+	e := prog.Endpoints[0]
+	if r := e.Request.Bodies["application/json"].Reference; r != "docparse.testUserWrap-request" {
+		t.Errorf("request body: %q", r)
+	}
+	if r := e.Responses["200"].Bodies["application/json"].Reference; r != "docparse.testUserWrap-response" {
+		t.Errorf("response body: %q", r)
+	}
+	if r := e.Responses["400"].Bodies["application/json"].Reference; r != "mail.Address" {
+		t.Errorf("response body: %q", r)
+	}
+
+	req := prog.References["docparse.testUser-request"].Schema
+	if d := ztest.Diff(fmt.Sprint(req.PropertyOrder, req.Required), "[Password Name] [Password Name]"); d != "" {
+		t.Error(d)
+	}
+	resp := prog.References["docparse.testUser-response"].Schema
+	if d := ztest.Diff(fmt.Sprint(resp.PropertyOrder, resp.Required), "[ID Name] [Name]"); d != "" {
+		t.Error(d)
+	}
+
+	wrap := prog.References["docparse.testUserWrap-request"].Schema
+	if r := wrap.Properties["User"].Reference; r != "docparse.testUser-request" {
+		t.Errorf("User: %q", r)
+	}
+	if r := wrap.Properties["Users"].Items.Reference; r != "docparse.testUser-request" {
+		t.Errorf("Users: %q", r)
+	}
+}
+
+func TestSplitReadWriteCyclic(t *testing.T) {
+	// GetReference can't resolve cyclic types, so add them directly.
+	readonly := true
+	prog := NewProgram(false)
+	prog.References["docparse.testNode"] = Reference{Lookup: "docparse.testNode", Schema: &Schema{
+		Type:          "object",
+		PropertyOrder: []string{"ID", "Parent", "Children"},
+		Properties: map[string]*Schema{
+			"ID":       {Type: "integer", Readonly: &readonly},
+			"Parent":   {Reference: "docparse.testNode"},
+			"Children": {Type: "array", Items: &Schema{Reference: "docparse.testNode"}},
+		},
+	}}
+	prog.References["docparse.testList"] = Reference{Lookup: "docparse.testList", Schema: &Schema{
+		Type:          "object",
+		PropertyOrder: []string{"Next", "Name"},
+		Properties: map[string]*Schema{
+			"Next": {Reference: "docparse.testList"},
+			"Name": {Type: "string"},
+		},
+	}}
+
+	prog.Endpoints = []*Endpoint{{
+		Method: "POST",
+		Path:   "/node",
+		Request: Request{
+			Bodies: map[string]*Ref{"application/json": {Reference: "docparse.testNode"}},
+		},
+		Responses: map[string]Response{
+			"200": {Bodies: map[string]*Ref{"application/json": {Reference: "docparse.testList"}}},
+		},
+	}}
+	splitReadWrite(prog)
+
+	var refs []string
+	for k := range prog.References {
+		refs = append(refs, k)
+	}
+	sort.Strings(refs)
+	want := "[docparse.testList docparse.testNode-request]"
+	if d := ztest.Diff(fmt.Sprint(refs), want); d != "" {
+		t.Error(d)
+	}
+
+	node := prog.References["docparse.testNode-request"].Schema
+	if d := ztest.Diff(fmt.Sprint(node.PropertyOrder), "[Parent Children]"); d != "" {
+		t.Error(d)
+	}
+	if r := node.Properties["Parent"].Reference; r != "docparse.testNode-request" {
+		t.Errorf("Parent: %q", r)
+	}
+	if r := node.Properties["Children"].Items.Reference; r != "docparse.testNode-request" {
+		t.Errorf("Children: %q", r)
+	}
+}
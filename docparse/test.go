@@ -3,6 +3,11 @@ package docparse
 
 // For tests. We don't parse test files.
 
+import (
+	"database/sql"
+	"mime/multipart"
+)
+
 // testObject general documentation.
 type testObject struct {
 	// ID documentation {required}.
@@ -15,3 +20,74 @@ type testObject struct {
 
 	Bar []string
 }
+
+// testText is marshalled as a string with MarshalText().
+type testText struct {
+	v int
+}
+
+func (t testText) MarshalText() ([]byte, error) { return nil, nil }
+
+// testAmount is marshalled as a number with MarshalJSON().
+type testAmount struct {
+	v int
+}
+
+// MarshalJSON writes the amount in cents {type: integer}.
+func (t *testAmount) MarshalJSON() ([]byte, error) { return nil, nil }
+
+type testMarshalers struct {
+	Text   testText
+	Texts  []testText
+	Amount *testAmount
+}
+
+// testMoney is documented in a schema file {schema: testdata/schema/money.json}.
+type testMoney int64
+
+// testAddress is documented in a schema file.
+// {schema: testdata/schema/address.yaml}
+type testAddress struct {
+	Street string
+}
+
+type testSchemaFiles struct {
+	Price   testMoney
+	Prices  []testMoney
+	Address *testAddress
+}
+
+type testNullable struct {
+	Str      string
+	Ptr      *string
+	Explicit string // {nullable}
+	SQLStr   sql.NullString
+	SQLInt   sql.NullInt64
+	Ptrs     []*string
+}
+
+type testForm struct {
+	Name    string                  `form:"name"`
+	Avatar  *multipart.FileHeader   `form:"avatar"`
+	Photos  []*multipart.FileHeader `form:"photos"`
+	Resume  []byte                  `form:"resume"` // {file}
+	Comment string                  `form:"comment"`
+}
+
+type testQuery struct {
+	IDs    []int    `query:"id"` // {collection: multi}
+	Tags   []string `query:"tag"`
+	Search string   `query:"search"`
+}
+
+type testUser struct {
+	ID       int    // {readonly}
+	Password string // {writeonly, required}
+	Name     string // {required}
+}
+
+type testUserWrap struct {
+	User  testUser
+	Users []testUser
+	Note  string
+}
@@ -4,6 +4,8 @@ go 1.19
 
 require (
 	golang.org/x/tools v0.1.12
+	gopkg.in/yaml.v3 v3.0.1
+	zgo.at/errors v1.1.0
 	zgo.at/sconfig v1.2.2
 	zgo.at/zstd v0.0.0-20221013104704-16fa49fadc62
 )
@@ -11,5 +13,4 @@ require (
 require (
 	golang.org/x/mod v0.6.0-dev.0.20220419223038-86c51ed26bb4 // indirect
 	golang.org/x/sys v0.0.0-20220722155257-8c9f86f7a55f // indirect
-	zgo.at/errors v1.1.0 // indirect
 )
@@ -0,0 +1,685 @@
+// Package goclient generates a Go HTTP client for the API.
+//
+// The client has one method for every endpoint, named after the handler
+// function if the endpoint is documented on a function, or after the method
+// and path otherwise ("POST /bikes/{id}" becomes PostBikesID).
+//
+// Path parameters are method arguments, and query and form parameters are
+// passed as a generated struct. Request and response bodies use the same Go
+// types as the server, imported from their original package; unexported types
+// are passed as any and not decoded. Request bodies are sent with the JSON
+// Content-Type if there is more than one (see docparse.MainContentType), and
+// responses with more than one are decoded according to the Content-Type
+// header. Responses with a status code that isn't
+// documented return an *Error.
+package goclient
+
+import (
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"zgo.at/kommentaar/docparse"
+)
+
+// WriteClient writes the Go client.
+func WriteClient(w io.Writer, prog *docparse.Program) error {
+	g := gen{
+		prog:    prog,
+		imports: map[string]string{"context": "", "net/http": "", "io": "", "fmt": "", "bytes": "", "strings": ""},
+		aliases: map[string]string{},
+		names:   map[string]struct{}{"Client": {}, "New": {}, "Error": {}},
+		files:   map[string]*ast.File{},
+	}
+
+	pkg := prog.Config.GoClientPackage
+	if pkg == "" {
+		pkg = "client"
+	}
+
+	body := new(strings.Builder)
+	auth := false
+	for _, e := range prog.Endpoints {
+		if g.auth(e) {
+			auth = true
+		}
+		err := g.endpoint(body, e)
+		if err != nil {
+			return err
+		}
+	}
+
+	b := new(strings.Builder)
+	b.WriteString("// Code generated by kommentaar; DO NOT EDIT.\n\n")
+	if prog.Config.Title != "" {
+		fmt.Fprintf(b, "// Package %s is a client for %s.\n", pkg, prog.Config.Title)
+	}
+	fmt.Fprintf(b, "package %s\n\n", pkg)
+
+	paths := make([]string, 0, len(g.imports))
+	for p := range g.imports {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	b.WriteString("import (\n")
+	std := true
+	for _, p := range paths {
+		if std && strings.Contains(strings.Split(p, "/")[0], ".") {
+			std = false
+			b.WriteString("\n")
+		}
+		// Always use the alias for imported packages, as the package name
+		// may be different from the last path element.
+		if a := g.imports[p]; a != "" && (!std || a != filepath.Base(p)) {
+			fmt.Fprintf(b, "%s %q\n", a, p)
+		} else {
+			fmt.Fprintf(b, "%q\n", p)
+		}
+	}
+	b.WriteString(")\n\n")
+
+	b.WriteString(clientCode(prog, auth, g.mediaType))
+	b.WriteString(body.String())
+
+	src, err := format.Source([]byte(b.String()))
+	if err != nil {
+		return fmt.Errorf("goclient: formatting generated code: %v", err)
+	}
+	_, err = w.Write(src)
+	return err
+}
+
+// Client type and helpers.
+func clientCode(prog *docparse.Program, auth, mediaType bool) string {
+	b := new(strings.Builder)
+	title := prog.Config.Title
+	if title == "" {
+		title = "the"
+	}
+	fmt.Fprintf(b, "// Client for %s API.\n", title)
+	b.WriteString("type Client struct {\n")
+	b.WriteString("BaseURL    string       // Base URL to prefix paths with, e.g. \"https://api.example.com/v1\".\n")
+	b.WriteString("HTTPClient *http.Client // HTTP client; http.DefaultClient is used if nil.\n")
+	if auth {
+		b.WriteString("Username   string       // Username for basic authentication.\n")
+		b.WriteString("Password   string       // Password for basic authentication.\n")
+	}
+	b.WriteString("}\n\n")
+
+	b.WriteString(`// New creates a new client.
+func New(baseURL string) *Client {
+	return &Client{BaseURL: strings.TrimRight(baseURL, "/")}
+}
+
+// Error is returned for responses with a status code that isn't documented.
+type Error struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("unexpected status %d: %s", e.StatusCode, bytes.TrimSpace(e.Body))
+}
+
+func (c *Client) do(req *http.Request, auth bool) (*http.Response, []byte, error) {
+`)
+	if auth {
+		b.WriteString("if auth && c.Username != \"\" {\nreq.SetBasicAuth(c.Username, c.Password)\n}\n")
+	} else {
+		b.WriteString("_ = auth\n")
+	}
+	b.WriteString(`	hc := c.HTTPClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp, b, nil
+}
+`)
+	if mediaType {
+		b.WriteString(`
+func mediaType(h http.Header) string {
+	mt, _, _ := mime.ParseMediaType(h.Get("Content-Type"))
+	return mt
+}
+`)
+	}
+	return b.String()
+}
+
+type gen struct {
+	prog    *docparse.Program
+	imports map[string]string    // Import path → alias.
+	aliases map[string]string    // Alias → import path.
+	names   map[string]struct{}  // Method names.
+	files   map[string]*ast.File // Parsed files, to find handler names.
+	fset    *token.FileSet
+
+	mediaType bool // Add the mediaType() helper.
+}
+
+func (g *gen) use(path string) { g.imports[path] = "" }
+
+// Get the alias for an import path.
+func (g *gen) alias(path string) string {
+	if a, ok := g.imports[path]; ok && a != "" {
+		return a
+	}
+
+	base := reNonIdent.ReplaceAllString(filepath.Base(path), "")
+	if base == "" || base[0] >= '0' && base[0] <= '9' || token.Lookup(base).IsKeyword() {
+		base = "pkg" + base
+	}
+	a := base
+	for i := 2; ; i++ {
+		if _, ok := g.aliases[a]; !ok && !stdNames[a] {
+			break
+		}
+		a = base + strconv.Itoa(i)
+	}
+	g.aliases[a] = path
+	g.imports[path] = a
+	return a
+}
+
+// Names used in the generated code, which imported packages can't use.
+var stdNames = map[string]bool{
+	"bytes": true, "context": true, "fmt": true, "http": true, "io": true, "strings": true,
+	"url": true, "json": true, "xml": true, "multipart": true, "mime": true,
+}
+
+var (
+	reNonIdent = regexp.MustCompile(`[^a-zA-Z0-9_]+`)
+	reWords    = regexp.MustCompile(`[a-zA-Z0-9]+`)
+)
+
+// Common initialisms to uppercase in names.
+var initialisms = map[string]string{"id": "ID", "url": "URL", "api": "API", "http": "HTTP", "json": "JSON", "xml": "XML", "uuid": "UUID"}
+
+// Get an exported Go name for a string, e.g. "page-size" becomes "PageSize".
+func goName(s string) string {
+	var b strings.Builder
+	for _, w := range reWords.FindAllString(s, -1) {
+		if i, ok := initialisms[strings.ToLower(w)]; ok {
+			b.WriteString(i)
+			continue
+		}
+		b.WriteString(strings.ToUpper(w[:1]) + w[1:])
+	}
+	n := b.String()
+	if n == "" || n[0] >= '0' && n[0] <= '9' {
+		n = "P" + n
+	}
+	return n
+}
+
+// Get an argument name for a parameter.
+func argName(s string) string {
+	n := goName(s)
+	switch {
+	case strings.ToUpper(n) == n:
+		n = strings.ToLower(n)
+	default:
+		n = strings.ToLower(n[:1]) + n[1:]
+	}
+	if token.Lookup(n).IsKeyword() || stdNames[n] ||
+		map[string]bool{"c": true, "ctx": true, "query": true, "form": true, "body": true,
+			"path": true, "req": true, "resp": true, "r": true, "b": true, "err": true}[n] {
+		n += "Param"
+	}
+	return n
+}
+
+func (g *gen) auth(e *docparse.Endpoint) bool {
+	return e.Auth == "basic" || (e.Auth == "" && g.prog.Config.Auth == "basic")
+}
+
+// Get the method name: the handler function the endpoint is documented on, or
+// the method and path.
+func (g *gen) methodName(e *docparse.Endpoint) string {
+	name := g.handlerName(e)
+	if name == "" {
+		name = goName(strings.ToLower(e.Method) + " " + reParam.ReplaceAllString(e.Path, "$1"))
+	} else {
+		name = strings.ToUpper(name[:1]) + name[1:]
+	}
+
+	n := name
+	for i := 2; ; i++ {
+		if _, ok := g.names[n]; !ok {
+			break
+		}
+		n = name + strconv.Itoa(i)
+	}
+	g.names[n] = struct{}{}
+	return n
+}
+
+var reParam = regexp.MustCompile(`{(\w+)}`)
+
+// Get the name of the function the endpoint is documented on, if any.
+func (g *gen) handlerName(e *docparse.Endpoint) string {
+	if e.Pos.Filename == "" {
+		return ""
+	}
+	if g.fset == nil {
+		g.fset = token.NewFileSet()
+	}
+	f, ok := g.files[e.Pos.Filename]
+	if !ok {
+		f, _ = parser.ParseFile(g.fset, e.Pos.Filename, nil, parser.ParseComments)
+		g.files[e.Pos.Filename] = f
+	}
+	if f == nil {
+		return ""
+	}
+
+	for _, d := range f.Decls {
+		fd, ok := d.(*ast.FuncDecl)
+		if !ok || fd.Doc == nil {
+			continue
+		}
+		start, end := g.fset.Position(fd.Doc.Pos()), g.fset.Position(fd.Doc.End())
+		if start.Line <= e.Pos.Line && end.Line >= e.Pos.Line {
+			return fd.Name.Name
+		}
+	}
+	return ""
+}
+
+// Get the Go type for a reference; this returns an empty string if the type
+// can't be used outside the package.
+func (g *gen) refType(lookup string) string {
+	ref, ok := g.prog.References[lookup]
+	if !ok || !token.IsExported(ref.Name) || ref.Package == "" {
+		return ""
+	}
+	return g.alias(ref.Package) + "." + ref.Name
+}
+
+// Get the Go type for a parameter.
+func (g *gen) paramType(s *docparse.Schema) string {
+	switch s.Type {
+	case "integer":
+		return "int64"
+	case "number":
+		return "float64"
+	case "boolean":
+		return "bool"
+	case "file":
+		return "io.Reader"
+	case "array":
+		if s.Items == nil {
+			return "[]string"
+		}
+		return "[]" + g.paramType(s.Items)
+	default:
+		return "string"
+	}
+}
+
+// Zero value check for a parameter.
+func zeroCheck(typ, v string) string {
+	switch {
+	case typ == "bool":
+		return v
+	case typ == "string":
+		return v + ` != ""`
+	case typ == "io.Reader":
+		return v + " != nil"
+	case strings.HasPrefix(typ, "[]"):
+		return "len(" + v + ") > 0"
+	default:
+		return v + " != 0"
+	}
+}
+
+type param struct {
+	name, field, typ string
+	required         bool
+	collection       string
+}
+
+// Get the parameters for a path, query, or form reference.
+func (g *gen) params(r *docparse.Ref) []param {
+	if r == nil {
+		return nil
+	}
+	ref, ok := g.prog.References[r.Reference]
+	if !ok || ref.Schema == nil {
+		return nil
+	}
+
+	var params []param
+	for _, name := range ref.Schema.PropertyOrder {
+		p := ref.Schema.Properties[name]
+		if p.OmitDoc {
+			continue
+		}
+		params = append(params, param{
+			name:       name,
+			field:      goName(name),
+			typ:        g.paramType(p),
+			required:   len(p.Required) > 0,
+			collection: p.CollectionFormat,
+		})
+	}
+	return params
+}
+
+// Write the code to set a parameter value.
+func setParam(b *strings.Builder, p param, v, set string) {
+	if !p.required {
+		fmt.Fprintf(b, "if %s {\n", zeroCheck(p.typ, v))
+	}
+	if strings.HasPrefix(p.typ, "[]") {
+		if p.collection == "multi" {
+			fmt.Fprintf(b, "for _, v := range %s {\n%s(%q, fmt.Sprint(v))\n}\n", v, set, p.name)
+		} else {
+			sep := map[string]string{"ssv": " ", "tsv": "\t", "pipes": "|"}[p.collection]
+			if sep == "" {
+				sep = ","
+			}
+			fmt.Fprintf(b, "{\ns := make([]string, 0, len(%s))\nfor _, v := range %[1]s {\ns = append(s, fmt.Sprint(v))\n}\n", v)
+			fmt.Fprintf(b, "%s(%q, strings.Join(s, %q))\n}\n", set, p.name, sep)
+		}
+	} else {
+		fmt.Fprintf(b, "%s(%q, fmt.Sprint(%s))\n", set, p.name, v)
+	}
+	if !p.required {
+		b.WriteString("}\n")
+	}
+}
+
+// Write a struct type for query or form parameters.
+func paramStruct(b *strings.Builder, name, doc string, params []param) {
+	fmt.Fprintf(b, "// %s are the %s.\ntype %[1]s struct {\n", name, doc)
+	for _, p := range params {
+		req := ""
+		if p.required {
+			req = " // Required."
+		}
+		fmt.Fprintf(b, "%s %s%s\n", p.field, p.typ, req)
+	}
+	b.WriteString("}\n\n")
+}
+
+func (g *gen) endpoint(b *strings.Builder, e *docparse.Endpoint) error {
+	name := g.methodName(e)
+	path := g.prog.Config.Prefix + e.Path
+
+	var (
+		args  = []string{"ctx context.Context"}
+		query = g.params(e.Request.Query)
+		form  = g.params(e.Request.Form)
+	)
+
+	// Path parameters.
+	pathParams := map[string]param{}
+	for _, p := range g.params(e.Request.Path) {
+		pathParams[p.name] = p
+	}
+	pathCode := strconv.Quote(path)
+	for _, pp := range docparse.PathParams(path) {
+		p, ok := pathParams[pp]
+		if !ok {
+			p = param{name: pp, typ: "string"}
+		}
+		a := argName(pp)
+		args = append(args, a+" "+p.typ)
+		pathCode = strings.Replace(pathCode, "{"+pp+"}",
+			`" + url.PathEscape(fmt.Sprint(`+a+`)) + "`, 1)
+		g.use("net/url")
+	}
+	pathCode = strings.TrimSuffix(strings.TrimPrefix(pathCode, `"" + `), ` + ""`)
+
+	if len(query) > 0 {
+		args = append(args, "query "+name+"Query")
+		paramStruct(b, name+"Query", "query parameters for "+name, query)
+	}
+	if len(form) > 0 {
+		args = append(args, "form "+name+"Form")
+		paramStruct(b, name+"Form", "form parameters for "+name, form)
+	}
+
+	ct := docparse.MainContentType(e.Request.Bodies)
+	bodyEnc := ""
+	if body := e.Request.Bodies[ct]; body != nil && len(form) == 0 {
+		typ := g.refType(body.Reference)
+		switch {
+		case body.Reference == "":
+			typ, bodyEnc = "io.Reader", "raw"
+		case strings.Contains(ct, "json"):
+			bodyEnc = "json"
+			g.use("encoding/json")
+		case strings.Contains(ct, "xml"):
+			bodyEnc = "xml"
+			g.use("encoding/xml")
+		default:
+			typ, bodyEnc = "io.Reader", "raw"
+		}
+		if typ == "" {
+			typ = "any"
+		}
+		args = append(args, "body "+typ)
+	}
+
+	// Responses.
+	codes := make([]string, 0, len(e.Responses))
+	for c := range e.Responses {
+		codes = append(codes, c)
+	}
+	sort.Slice(codes, func(i, j int) bool { return codeOrder(codes[i]) < codeOrder(codes[j]) })
+	decode := map[string][]respBody{}
+
+	fmt.Fprintf(b, "// %sResponse is the response for %s %s.\n", name, e.Method, path)
+	fmt.Fprintf(b, "type %sResponse struct {\n", name)
+	b.WriteString("StatusCode int\nHeader http.Header\nBody []byte // Raw response body.\n")
+	for _, c := range codes {
+		decode[c] = g.respBodies(c, e.Responses[c])
+		seen := map[string]bool{}
+		for _, rb := range decode[c] {
+			if seen[rb.field] {
+				continue
+			}
+			seen[rb.field] = true
+			comment := strings.TrimSpace(c + " " + docparse.StatusText(c))
+			if rb.field != statusField(c) {
+				comment += " (" + rb.ct + ")"
+			}
+			fmt.Fprintf(b, "%s *%s // %s\n", rb.field, rb.typ, comment)
+		}
+	}
+	b.WriteString("}\n\n")
+
+	// The method.
+	fmt.Fprintf(b, "// %s sends %s %s.\n", name, e.Method, path)
+	if e.Tagline != "" {
+		fmt.Fprintf(b, "//\n// %s\n", e.Tagline)
+	}
+	fmt.Fprintf(b, "func (c *Client) %s(%s) (*%[1]sResponse, error) {\n", name, strings.Join(args, ", "))
+	fmt.Fprintf(b, "path := %s\n", pathCode)
+
+	if len(query) > 0 {
+		g.use("net/url")
+		b.WriteString("q := url.Values{}\n")
+		for _, p := range query {
+			setParam(b, p, "query."+p.field, "q.Add")
+		}
+		b.WriteString("if len(q) > 0 {\npath += \"?\" + q.Encode()\n}\n")
+	}
+	b.WriteString("\n")
+
+	var reqBody string
+	switch {
+	case len(form) > 0 && e.Request.FormContentType == "multipart/form-data":
+		g.use("mime/multipart")
+		reqBody = "buf"
+		b.WriteString("buf := new(bytes.Buffer)\nmw := multipart.NewWriter(buf)\n")
+		for _, p := range form {
+			v := "form." + p.field
+			if p.typ == "io.Reader" {
+				fmt.Fprintf(b, "if %s != nil {\n", v)
+				fmt.Fprintf(b, "fw, err := mw.CreateFormFile(%q, %[1]q)\nif err != nil {\nreturn nil, err\n}\n", p.name)
+				fmt.Fprintf(b, "if _, err := io.Copy(fw, %s); err != nil {\nreturn nil, err\n}\n}\n", v)
+				continue
+			}
+			setParam(b, p, v, "_ = mw.WriteField")
+		}
+		b.WriteString("if err := mw.Close(); err != nil {\nreturn nil, err\n}\n")
+		ct = ""
+	case len(form) > 0:
+		g.use("net/url")
+		reqBody = "strings.NewReader(f.Encode())"
+		b.WriteString("f := url.Values{}\n")
+		for _, p := range form {
+			setParam(b, p, "form."+p.field, "f.Add")
+		}
+		ct = "application/x-www-form-urlencoded"
+	case bodyEnc == "json" || bodyEnc == "xml":
+		reqBody = "bytes.NewReader(reqBody)"
+		fmt.Fprintf(b, "reqBody, err := %s.Marshal(body)\nif err != nil {\nreturn nil, err\n}\n", bodyEnc)
+	case bodyEnc == "raw":
+		reqBody = "body"
+	default:
+		reqBody = "nil"
+		ct = ""
+	}
+
+	fmt.Fprintf(b, "req, err := http.NewRequestWithContext(ctx, %q, c.BaseURL+path, %s)\n", e.Method, reqBody)
+	b.WriteString("if err != nil {\nreturn nil, err\n}\n")
+	switch {
+	case len(form) > 0 && e.Request.FormContentType == "multipart/form-data":
+		b.WriteString("req.Header.Set(\"Content-Type\", mw.FormDataContentType())\n")
+	case ct != "":
+		fmt.Fprintf(b, "req.Header.Set(\"Content-Type\", %q)\n", ct)
+	}
+
+	fmt.Fprintf(b, "\nresp, b, err := c.do(req, %t)\nif err != nil {\nreturn nil, err\n}\n", g.auth(e))
+	fmt.Fprintf(b, "r := &%sResponse{StatusCode: resp.StatusCode, Header: resp.Header, Body: b}\n", name)
+
+	b.WriteString("switch {\n")
+	hasDefault := false
+	for _, c := range codes {
+		switch {
+		case c == "default":
+			hasDefault = true
+			b.WriteString("default:\n")
+		case strings.HasSuffix(c, "XX"):
+			fmt.Fprintf(b, "case resp.StatusCode/100 == %c:\n", c[0])
+		default:
+			fmt.Fprintf(b, "case resp.StatusCode == %s:\n", c)
+		}
+
+		// Decode based on the Content-Type if the response can have more than
+		// one.
+		bodies := decode[c]
+		multi := len(e.Responses[c].Bodies) > 1
+		if multi && len(bodies) > 0 {
+			g.mediaType = true
+			g.use("mime")
+			b.WriteString("switch mediaType(resp.Header) {\n")
+		}
+		for _, rb := range bodies {
+			if multi {
+				fmt.Fprintf(b, "case %q:\n", rb.ct)
+			}
+			g.use("encoding/" + rb.dec)
+			f := "r." + rb.field
+			fmt.Fprintf(b, "%s = new(%s)\n", f, rb.typ)
+			fmt.Fprintf(b, "if err := %s.Unmarshal(b, %s); err != nil {\n", rb.dec, f)
+			fmt.Fprintf(b, "return nil, fmt.Errorf(\"%s: decoding %%d response: %%w\", resp.StatusCode, err)\n}\n", name)
+		}
+		if multi && len(bodies) > 0 {
+			b.WriteString("}\n")
+		}
+	}
+	if !hasDefault {
+		b.WriteString("default:\nreturn nil, &Error{StatusCode: resp.StatusCode, Header: resp.Header, Body: b}\n")
+	}
+	b.WriteString("}\nreturn r, nil\n}\n\n")
+	return nil
+}
+
+// Response body to decode.
+type respBody struct {
+	ct    string // Content-Type.
+	typ   string // Go type.
+	dec   string // Decoder: json or xml.
+	field string // Field in the response struct.
+}
+
+// Get all response bodies that can be decoded, sorted by Content-Type. If they
+// don't all have the same type then every Content-Type gets its own field.
+func (g *gen) respBodies(code string, r docparse.Response) []respBody {
+	var (
+		bodies []respBody
+		types  = map[string]bool{}
+	)
+	for _, ct := range docparse.ContentTypes(r.Bodies) {
+		body := r.Bodies[ct]
+		if body.Reference == "" {
+			continue
+		}
+		dec := ""
+		switch {
+		case strings.Contains(ct, "json"):
+			dec = "json"
+		case strings.Contains(ct, "xml"):
+			dec = "xml"
+		default:
+			continue
+		}
+		typ := g.refType(body.Reference)
+		if typ == "" {
+			continue
+		}
+		types[typ] = true
+		bodies = append(bodies, respBody{ct: ct, typ: typ, dec: dec, field: statusField(code)})
+	}
+	if len(types) > 1 {
+		for i := range bodies {
+			bodies[i].field += goName(bodies[i].ct)
+		}
+	}
+	return bodies
+}
+
+// Get the response field name for a status code: Status200, Status4XX,
+// StatusDefault.
+func statusField(c string) string {
+	if c == "default" {
+		return "StatusDefault"
+	}
+	return "Status" + c
+}
+
+// Sort status codes: exact codes, ranges, and default.
+func codeOrder(c string) string {
+	switch {
+	case c == "default":
+		return "2" + c
+	case strings.HasSuffix(c, "XX"):
+		return "1" + c
+	default:
+		return "0" + c
+	}
+}
@@ -0,0 +1,36 @@
+package goclient_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"zgo.at/kommentaar/goclient"
+	"zgo.at/kommentaar/internal/golden"
+)
+
+func TestWriteClient(t *testing.T) {
+	golden.Test(t, "../testdata/goclient/src", "want.txt", goclient.WriteClient,
+		func(t *testing.T, path string, out []byte) {
+			// Make sure the generated client compiles.
+			if testing.Short() {
+				return
+			}
+			if _, err := exec.LookPath("go"); err != nil {
+				t.Skip("go not in PATH")
+			}
+			dir, err := os.MkdirTemp(path, "client-")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+			if err := os.WriteFile(filepath.Join(dir, "client.go"), out, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			vet, err := exec.Command("go", "vet", "./"+dir).CombinedOutput()
+			if err != nil {
+				t.Errorf("go vet: %s\n%s", err, vet)
+			}
+		})
+}
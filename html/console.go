@@ -0,0 +1,178 @@
+package html
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"strings"
+
+	"zgo.at/kommentaar/docparse"
+)
+
+// Settings for the "try it" console: the base URL and Authorization header.
+func consoleSettings(prog *docparse.Program) func() template.HTML {
+	return func() template.HTML {
+		base := ""
+		if s := servers(prog)(); len(s) > 0 {
+			base = s[0].Expand()
+		}
+		auth := ""
+		if prog.Config.Auth == "basic" {
+			auth = "Basic dXNlcjpwYXNzd29yZA=="
+		}
+
+		return template.HTML(fmt.Sprintf(`
+	<div class="console-settings">
+		<h2>Try it</h2>
+		<label>Base URL <input id="console-base" type="url" value="%s"></label>
+		<label>Authorization <input id="console-auth" type="text" placeholder="%s"></label>
+	</div>`, e(base), e(auth)))
+	}
+}
+
+// Console form to send a request to the endpoint.
+func console(prog *docparse.Program) func(*docparse.Endpoint) template.HTML {
+	return func(ep *docparse.Endpoint) template.HTML {
+		b := new(strings.Builder)
+		fmt.Fprintf(b, `<form class="console" data-method="%s" data-path="%s" data-form-ct="%s">`,
+			e(ep.Method), e(ep.Path), e(ep.Request.FormContentType))
+		b.WriteString("<h4>Try it</h4>\n")
+
+		if ep.Request.Path != nil {
+			consoleParams(b, prog, "path", ep.Request.Path)
+		} else {
+			for _, p := range docparse.PathParams(ep.Path) {
+				consoleInput(b, "path", p, "text", "", true)
+			}
+		}
+		if ep.Request.Query != nil {
+			consoleParams(b, prog, "query", ep.Request.Query)
+		}
+		if ep.Request.Form != nil {
+			consoleParams(b, prog, "form", ep.Request.Form)
+		}
+		if len(ep.Request.Bodies) > 0 {
+			ct := docparse.MainContentType(ep.Request.Bodies)
+			ex := ep.Request.Bodies[ct].Example
+			if ex == nil {
+				if ref, ok := prog.References[ep.Request.Bodies[ct].Reference]; ok {
+					ex, _ = json.Marshal(docparse.ExampleFor(prog, ref.Schema))
+				}
+			}
+			body, _ := json.MarshalIndent(json.RawMessage(ex), "", "    ")
+			if ex == nil {
+				body = nil
+			}
+			fmt.Fprintf(b, `<label><code>body</code> <sup>(%s)</sup><br><textarea data-in="body" data-ct="%s" rows="8">%s</textarea></label>`+"\n",
+				e(ct), e(ct), e(string(body)))
+		}
+
+		b.WriteString(`<button type="submit">Send</button>` + "\n")
+		b.WriteString(`<pre class="console-response"></pre>` + "\n")
+		b.WriteString("</form>")
+		return template.HTML(b.String())
+	}
+}
+
+func consoleParams(b *strings.Builder, prog *docparse.Program, in string, r *docparse.Ref) {
+	ref, ok := prog.References[r.Reference]
+	if !ok || ref.Schema == nil {
+		return
+	}
+
+	for _, name := range ref.Schema.PropertyOrder {
+		p := ref.Schema.Properties[name]
+		if p.OmitDoc {
+			continue
+		}
+
+		typ, value := "text", ""
+		switch {
+		case p.Type == "file" || (p.Items != nil && p.Items.Type == "file"):
+			typ = "file"
+		case p.Example != nil:
+			value = fmt.Sprint(p.Example)
+		case p.Default != nil:
+			value = fmt.Sprint(p.Default)
+		case in == "path":
+			value = fmt.Sprint(docparse.ExampleFor(prog, p))
+		}
+		consoleInput(b, in, name, typ, value, in == "path" || len(p.Required) > 0)
+	}
+}
+
+func consoleInput(b *strings.Builder, in, name, typ, value string, required bool) {
+	req := ""
+	if required {
+		req = " required"
+	}
+	fmt.Fprintf(b, `<label><code>%s</code> <sup>(%s)</sup> <input data-in="%[2]s" name="%[1]s" type="%[3]s" value="%[4]s"%[5]s></label><br>`+"\n",
+		e(name), in, typ, e(value), req)
+}
+
+// JavaScript for the console; this is in the page so that it works offline.
+const consoleJS = template.JS(`
+		// Send requests from the "try it" console.
+		document.addEventListener('submit', function(e) {
+			var form = e.target
+			if (!form.classList.contains('console'))
+				return
+			e.preventDefault()
+
+			var out     = form.getElementsByClassName('console-response')[0],
+			    base    = document.getElementById('console-base').value.replace(/\/$/, ''),
+			    auth    = document.getElementById('console-auth').value,
+			    path    = form.dataset.path,
+			    query   = [],
+			    headers = {},
+			    body    = null,
+			    data    = form.dataset.formCt === 'multipart/form-data' ? new FormData() : new URLSearchParams(),
+			    hasForm = false
+
+			var inputs = form.querySelectorAll('[data-in]')
+			for (var i = 0; i < inputs.length; i++) {
+				var input = inputs[i]
+				switch (input.dataset.in) {
+				case 'path':
+					path = path.replace('{' + input.name + '}', encodeURIComponent(input.value))
+					break
+				case 'query':
+					if (input.value !== '')
+						query.push(encodeURIComponent(input.name) + '=' + encodeURIComponent(input.value))
+					break
+				case 'form':
+					hasForm = true
+					if (input.type === 'file') {
+						for (var j = 0; j < input.files.length; j++)
+							data.append(input.name, input.files[j])
+					}
+					else if (input.value !== '')
+						data.append(input.name, input.value)
+					break
+				case 'body':
+					if (input.value.trim() !== '') {
+						body = input.value
+						headers['Content-Type'] = input.dataset.ct
+					}
+					break
+				}
+			}
+			if (hasForm)
+				body = data
+			if (auth !== '')
+				headers['Authorization'] = auth
+
+			out.textContent = 'Sending…'
+			var url = base + path + (query.length ? '?' + query.join('&') : '')
+			fetch(url, {method: form.dataset.method, headers: headers, body: body}).then(function(r) {
+				var head = r.status + ' ' + r.statusText + '\n'
+				r.headers.forEach(function(v, k) { head += k + ': ' + v + '\n' })
+				return r.text().then(function(text) {
+					try { text = JSON.stringify(JSON.parse(text), null, 4) } catch (err) { }
+					out.textContent = head + '\n' + text
+				})
+			}).catch(function(err) {
+				out.textContent = 'Error: ' + err
+			})
+		})
+`)
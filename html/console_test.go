@@ -0,0 +1,195 @@
+package html
+
+import (
+	"bytes"
+	"encoding/json"
+	"html"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"regexp"
+	"strings"
+	"testing"
+
+	"zgo.at/kommentaar/docparse"
+)
+
+func TestConsole(t *testing.T) {
+	prog := docparse.NewProgram(false)
+	prog.Config.Packages = []string{"../example/..."}
+	prog.Config.Output = WriteHTML
+	prog.Config.HTMLConsole = true
+
+	buf := new(bytes.Buffer)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(buf.Bytes())
+	}))
+	defer srv.Close()
+	prog.Config.Host = strings.TrimPrefix(srv.URL, "http://")
+	prog.Config.Schemes = []string{"http"}
+
+	err := docparse.FindComments(buf, prog)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(b)
+
+	if n := strings.Count(out, `<form class="console"`); n != len(prog.Endpoints) {
+		t.Errorf("%d console forms for %d endpoints", n, len(prog.Endpoints))
+	}
+	for _, want := range []string{
+		`id="console-base" type="url" value="` + srv.URL + `"`,
+		`data-in="path" name="id" type="text"`,
+		`data-in="body"`,
+		`fetch(url`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("%q not in output", want)
+		}
+	}
+	for _, ext := range []string{`src="http`, `href="http`, `@import`} {
+		if strings.Contains(out, ext) {
+			t.Errorf("external asset %q in output", ext)
+		}
+	}
+}
+
+func TestConsoleDisabled(t *testing.T) {
+	prog := docparse.NewProgram(false)
+	prog.Config.Packages = []string{"../example/..."}
+	prog.Config.Output = WriteHTML
+
+	buf := new(bytes.Buffer)
+	err := docparse.FindComments(buf, prog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), `class="console`) {
+		t.Error("console in output while not enabled")
+	}
+}
+
+// Send a request from the console form with the console JavaScript, using a
+// minimal DOM.
+func TestConsoleSend(t *testing.T) {
+	if testing.Short() {
+		t.Skip("-short")
+	}
+	node, err := exec.LookPath("node")
+	if err != nil {
+		t.Skip("node not in PATH")
+	}
+
+	var (
+		gotMethod, gotPath, gotQuery, gotCT, gotAuth string
+		gotBody                                      []byte
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod, gotPath, gotQuery = r.Method, r.URL.Path, r.URL.RawQuery
+		gotCT, gotAuth = r.Header.Get("Content-Type"), r.Header.Get("Authorization")
+		gotBody, _ = ioutil.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer srv.Close()
+
+	prog := docparse.NewProgram(false)
+	prog.Config.Packages = []string{"../testdata/console"}
+	prog.Config.Output = WriteHTML
+	prog.Config.HTMLConsole = true
+	prog.Config.StructTag = "json"
+	buf := new(bytes.Buffer)
+	err = docparse.FindComments(buf, prog)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	form := regexp.MustCompile(`(?s)<form class="console".*?</form>`).FindString(buf.String())
+	if form == "" {
+		t.Fatal("no console form in output")
+	}
+	attr := func(tag, name string) string {
+		m := regexp.MustCompile(` ` + name + `="(.*?)"`).FindStringSubmatch(tag)
+		if m == nil {
+			return ""
+		}
+		return html.UnescapeString(m[1])
+	}
+
+	// Fill in the form like a user would.
+	values := map[string]string{"id": "42", "dry_run": "true", "fields": "a b"}
+	var inputs []map[string]any
+	for _, in := range regexp.MustCompile(`<input [^>]*>`).FindAllString(form, -1) {
+		inputs = append(inputs, map[string]any{
+			"name": attr(in, "name"), "type": attr(in, "type"), "value": values[attr(in, "name")],
+			"dataset": map[string]string{"in": attr(in, "data-in")},
+		})
+	}
+	ta := regexp.MustCompile(`(?s)(<textarea [^>]*>)(.*?)</textarea>`).FindStringSubmatch(form)
+	if ta == nil {
+		t.Fatal("no body textarea in form")
+	}
+	body := html.UnescapeString(ta[2])
+	inputs = append(inputs, map[string]any{
+		"value":   body,
+		"dataset": map[string]string{"in": attr(ta[1], "data-in"), "ct": attr(ta[1], "data-ct")},
+	})
+
+	j := func(v any) string { b, _ := json.Marshal(v); return string(b) }
+	script := `
+		var handler, settings = {
+			'console-base': {value: ` + j(srv.URL) + `},
+			'console-auth': {value: 'Bearer x'},
+		}
+		global.document = {
+			addEventListener: function(ev, f) { handler = f },
+			getElementById:   function(id) { return settings[id] },
+		}
+		` + string(consoleJS) + `
+		var out = {}
+		handler({preventDefault: function() {}, target: {
+			classList:              {contains: function(c) { return c === 'console' }},
+			dataset:                ` + j(map[string]string{"method": attr(form, "data-method"), "path": attr(form, "data-path"), "formCt": attr(form, "data-form-ct")}) + `,
+			getElementsByClassName: function() { return [out] },
+			querySelectorAll:       function() { return ` + j(inputs) + ` },
+		}})
+		process.on('beforeExit', function() { console.log(out.textContent) })`
+
+	stdout, err := exec.Command(node, "-e", script).CombinedOutput()
+	if err != nil {
+		t.Fatalf("node: %s\n%s", err, stdout)
+	}
+
+	if gotMethod != "PUT" {
+		t.Errorf("method: %q", gotMethod)
+	}
+	if gotPath != "/bikes/42" {
+		t.Errorf("path: %q", gotPath)
+	}
+	if gotQuery != "dry_run=true&fields=a%20b" {
+		t.Errorf("query: %q", gotQuery)
+	}
+	if gotCT != "application/json" {
+		t.Errorf("Content-Type: %q", gotCT)
+	}
+	if gotAuth != "Bearer x" {
+		t.Errorf("Authorization: %q", gotAuth)
+	}
+	if string(gotBody) != body || !strings.Contains(body, `"frame"`) {
+		t.Errorf("body: %q; want %q", gotBody, body)
+	}
+	if !strings.Contains(string(stdout), "200 OK") || !strings.Contains(string(stdout), `"ok": true`) {
+		t.Errorf("wrong console output:\n%s", stdout)
+	}
+}
@@ -1,9 +1,36 @@
 // Package html outputs to HTML.
+//
+// The template can be changed with html-template in the configuration; this
+// can either be a complete template, or only {{define ..}} one or more of the
+// blocks:
+//
+//	head      Contents of <head>, with the default CSS; the dot is the Program.
+//	header    Title and base URLs at the top of the page; the dot is the Program.
+//	endpoint  A single endpoint; the dot is the Endpoint.
+//	model     A single model; the dot is the Reference.
+//	footer    JavaScript at the end of <body>; the dot is the Program.
+//
+// These functions can be used in the template:
+//
+//	doc        Render documentation (descriptions, Info) as HTML.
+//	para       Alias for doc.
+//	schema     Render a Schema as a list of properties.
+//	status     Get the status text for a response code ("404" -> "Not Found").
+//	example    Render an example (json.RawMessage).
+//	exampleFor Render an example generated from the reference with this name.
+//	refURL     Get the URL to the model for the reference with this name.
+//	samples    Get the snippet.Sample command lines for an Endpoint.
+//	console    Render the "try it" console form for an Endpoint.
+//	servers    Get the list of docparse.Server base URLs.
+//	prog       Get the docparse.Program.
+//	add        Add two integers.
 package html
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"go/doc/comment"
 	"html/template"
 	"io"
 	"net/http"
@@ -11,24 +38,100 @@ import (
 	"strings"
 
 	"zgo.at/kommentaar/docparse"
+	"zgo.at/kommentaar/snippet"
 	"zgo.at/zstd/zstring"
 )
 
 var funcMap = template.FuncMap{
-	"add":    func(a, b int) int { return a + b },
-	"status": func(c int) string { return http.StatusText(c) },
-	"schema": formatSchema,
-	"para":   para,
+	"add":     func(a, b int) int { return a + b },
+	"status":  docparse.StatusText,
+	"example": formatExample,
+	"mainCT":  docparse.MainContentType,
+
+	// Set in Template, as it needs the Program.
+	"doc":             func(string) template.HTML { return "" },
+	"para":            func(string) template.HTML { return "" },
+	"schema":          func(*docparse.Schema) template.HTML { return "" },
+	"exampleFor":      func(string) template.HTML { return "" },
+	"servers":         func() []docparse.Server { return nil },
+	"prog":            func() *docparse.Program { return nil },
+	"refURL":          func(string) string { return "" },
+	"samples":         func(*docparse.Endpoint) []snippet.Sample { return nil },
+	"console":         func(*docparse.Endpoint) template.HTML { return "" },
+	"consoleSettings": func() template.HTML { return "" },
+	"consoleJS":       func() template.JS { return consoleJS },
+	"styles":          func() []template.CSS { return nil },
+	"scripts":         func() []template.JS { return nil },
 }
 
 var e = template.HTMLEscapeString
 
-func para(s string) template.HTML {
-	return template.HTML("<p>" + strings.ReplaceAll(e(s), "\n\n", "</p><p>") + "</p>")
+// Render documentation as HTML; doc links to references link to the model.
+func doc(prog *docparse.Program, refURL func(string) string) func(string) template.HTML {
+	p := comment.Printer{
+		HeadingLevel: 5,
+		HeadingID:    func(*comment.Heading) string { return "" },
+		DocLinkURL: func(link *comment.DocLink) string {
+			if ref := docparse.RefLink(prog, link); ref != "" {
+				return refURL(ref)
+			}
+			return link.DefaultURL("https://pkg.go.dev")
+		},
+	}
+	return func(s string) template.HTML {
+		if s == "" {
+			return ""
+		}
+		return template.HTML(p.HTML(docparse.ParseDoc(prog, s)))
+	}
 }
 
-func formatSchema(schema *docparse.Schema) template.HTML {
-	if schema.OmitDoc {
+func formatExample(ex json.RawMessage) template.HTML {
+	b := new(bytes.Buffer)
+	err := json.Indent(b, ex, "", "    ")
+	if err != nil {
+		return template.HTML(fmt.Sprintf("json.Indent error: %v", err))
+	}
+	return template.HTML(`<pre class="example">` + e(b.String()) + "</pre>")
+}
+
+// Get the base URLs; the Basepath isn't added as the endpoint paths already
+// include it.
+func servers(prog *docparse.Program) func() []docparse.Server {
+	return func() []docparse.Server {
+		c := prog.Config
+		c.Basepath = ""
+		return c.AllServers()
+	}
+}
+
+// Get the command-line snippets for the endpoint; the endpoint path already
+// includes the Basepath.
+func samples(prog *docparse.Program) func(*docparse.Endpoint) []snippet.Sample {
+	return func(e *docparse.Endpoint) []snippet.Sample {
+		c := prog.Config
+		c.Basepath = ""
+		return snippet.Samples(prog, e, snippet.BaseURL(c))
+	}
+}
+
+// Generate an example for the reference.
+func exampleFor(prog *docparse.Program) func(string) template.HTML {
+	return func(lookup string) template.HTML {
+		ref, ok := prog.References[lookup]
+		if !ok || ref.Schema == nil {
+			return ""
+		}
+		ex, err := json.Marshal(docparse.ExampleFor(prog, ref.Schema))
+		if err != nil {
+			return template.HTML(fmt.Sprintf("json.Marshal error: %v", err))
+		}
+		return formatExample(ex)
+	}
+}
+
+func formatSchema(prog *docparse.Program, refURL func(string) string, schema *docparse.Schema) template.HTML {
+	if schema == nil || schema.OmitDoc {
 		return ""
 	}
 
@@ -40,6 +143,7 @@ func formatSchema(schema *docparse.Schema) template.HTML {
 		return template.HTML(template.HTMLEscaper(d))
 	}
 
+	render := doc(prog, refURL)
 	b := new(strings.Builder)
 	for _, name := range schema.PropertyOrder {
 		p := schema.Properties[name]
@@ -47,12 +151,18 @@ func formatSchema(schema *docparse.Schema) template.HTML {
 			continue
 		}
 
-		required := zstring.Contains(schema.Required, name)
+		// Path, query, and form parameters have required on the property.
+		required := zstring.Contains(schema.Required, name) || zstring.Contains(p.Required, name)
 
 		fmt.Fprintf(b, "<h4>%s <sup>", name)
-		if p.Type == "object" {
-			fmt.Fprintf(b, `<a href="#%s">%[1]s</a>`, p.Reference)
-		} else {
+		switch {
+		case p.Type == "object":
+			fmt.Fprintf(b, `<a href="%s">%s</a>`, e(refURL(p.Reference)), p.Reference)
+		case p.Type == "file":
+			b.WriteString(`<strong class="upload">file upload</strong>`)
+		case p.Type == "array" && p.Items != nil && p.Items.Type == "file":
+			b.WriteString(`<strong class="upload">file uploads</strong>`)
+		default:
 			b.WriteString(p.Type)
 		}
 
@@ -65,23 +175,38 @@ func formatSchema(schema *docparse.Schema) template.HTML {
 		if p.Readonly != nil && *p.Readonly {
 			b.WriteString(" [readonly]")
 		}
-		if p.Default != "" {
-			fmt.Fprintf(b, " [default: %s]", p.Default)
+		if p.Writeonly != nil && *p.Writeonly {
+			b.WriteString(" [writeonly]")
+		}
+		if p.Nullable {
+			b.WriteString(" [nullable]")
+		}
+		if p.Default != nil {
+			def, _ := json.Marshal(p.Default)
+			fmt.Fprintf(b, " [default: %s]", e(string(def)))
 		}
 		if p.Minimum != 0 || p.Maximum != 0 {
 			fmt.Fprintf(b, " [range: %d-%d]", p.Minimum, p.Maximum)
 		}
+		if p.CollectionFormat != "" {
+			fmt.Fprintf(b, " [collection: %s]", p.CollectionFormat)
+		}
+		if p.Example != nil {
+			ex, _ := json.Marshal(p.Example)
+			fmt.Fprintf(b, " [example: %s]", e(string(ex)))
+		}
 		if len(p.Enum) > 0 {
 			enum := make([]string, len(p.Enum))
 			for i := range p.Enum {
-				enum[i] = fmt.Sprintf("%q", p.Enum[i])
+				v, _ := json.Marshal(p.Enum[i])
+				enum[i] = string(v)
 			}
-			fmt.Fprintf(b, " [enum: %s]", strings.Join(enum, ", "))
+			fmt.Fprintf(b, " [enum: %s]", e(strings.Join(enum, ", ")))
 		}
 
-		if p.Type == "array" {
+		if p.Type == "array" && p.Items != nil && p.Items.Type != "file" {
 			if p.Items.Reference != "" {
-				fmt.Fprintf(b, ` [type: <a href="#%s">%[1]s</a>]`, p.Items.Reference)
+				fmt.Fprintf(b, ` [type: <a href="%s">%s</a>]`, e(refURL(p.Items.Reference)), p.Items.Reference)
 			} else {
 				fmt.Fprintf(b, " [type: %s]", p.Items.Type)
 			}
@@ -89,7 +214,7 @@ func formatSchema(schema *docparse.Schema) template.HTML {
 
 		b.WriteString("</sup></h4>\n")
 
-		fmt.Fprintf(b, "%s\n", para(p.Description))
+		fmt.Fprintf(b, "%s\n", render(p.Description))
 	}
 
 	return template.HTML(b.String())
@@ -99,6 +224,7 @@ var mainTpl = template.Must(template.New("mainTpl").Funcs(funcMap).Parse(`
 <!DOCTYPE html>
 <html lang="en">
 <head>
+{{- block "head" .}}
 	<meta http-equiv="Content-Type" content="text/html; charset=utf-8">
 	<meta name="viewport" content="width=device-width, initial-scale=1">
 	<title>{{.Config.Title}} API documentation {{.Config.Version}}</title>
@@ -194,6 +320,12 @@ var mainTpl = template.Must(template.New("mainTpl").Funcs(funcMap).Parse(`
 			white-space: pre-line;
 		}
 
+		.endpoint-info pre, .model pre {
+			background-color: #f7f7f7;
+			padding: .5em;
+			overflow-x: auto;
+		}
+
 		.resource {
 			display: inline-block;
 			min-width: 38rem;
@@ -208,10 +340,75 @@ var mainTpl = template.Must(template.New("mainTpl").Funcs(funcMap).Parse(`
 			display: inline-block;
 			min-width: 11rem;
 		}
+
+		.params {
+			margin-left: 2em;
+		}
+
+		.params h4 {
+			font-weight: normal;
+			font-family: monospace;
+		}
+
+		.upload {
+			color: #333;
+		}
+
+		.tab {
+			font: 14px monospace;
+			background-color: #eee;
+			border: 1px solid #ddd;
+			cursor: pointer;
+		}
+
+		.tab.active {
+			background-color: #fff;
+			border-bottom-color: #fff;
+		}
+
+		.tab-content {
+			display: none;
+			border: 1px solid #ddd;
+			margin-top: -1px;
+			padding: .2em .5em;
+		}
+
+		.tab-content.active {
+			display: block;
+		}
+
+		.console {
+			border-top: 1px solid #ddd;
+			margin-top: .5em;
+		}
+
+		.console textarea {
+			font: 14px monospace;
+			width: 100%;
+			max-width: 55em;
+		}
+
+		.console-response:empty {
+			display: none;
+		}
+
+		.console-response, .example {
+			background-color: #f7f7f7;
+			border: 1px solid #ddd;
+			padding: .5em;
+			margin: .2em 0;
+			font-size: 14px;
+			line-height: 1.4em;
+		}
 	</style>
+{{- end}}
+	{{- range styles}}
+	<style>{{.}}</style>
+	{{- end}}
 </head>
 
 <body>
+{{- block "header" .}}
 	<h1>{{.Config.Title}} API documentation {{.Config.Version}}</h1>
 
 	{{/*
@@ -229,11 +426,20 @@ var mainTpl = template.Must(template.New("mainTpl").Funcs(funcMap).Parse(`
 	{{end}}
 	*/}}
 
-	{{define "paramsTpl"}}
-		<ul>{{range $p := .Params}}
-			<li><code class="param-name">{{$p.Name}}</code> {{$p.Info}}</li>
-		{{- end}}</ul>
-	{{end}}
+	{{- with servers}}
+		<h2>Base URLs</h2>
+		<ul class="servers">
+			{{- range .}}
+				<li><code>{{.URL}}</code>{{if .Description}} – {{.Description}}{{end}}
+				{{- range $name, $v := .Variables}}
+					<br><code>{{"{"}}{{$name}}{{"}"}}</code>: {{$v.Default}}
+					{{- with $v.Enum}} (one of: {{range $i, $v := .}}{{if $i}}, {{end}}{{$v}}{{end}}){{end}}
+				{{- end}}</li>
+			{{- end}}
+		</ul>
+	{{- end}}
+	{{- if .Config.HTMLConsole}}{{consoleSettings}}{{end}}
+{{- end}}
 
 	<h2>Endpoints</h2>
 	{{range $i, $e := .Endpoints}}
@@ -247,6 +453,9 @@ var mainTpl = template.Must(template.New("mainTpl").Funcs(funcMap).Parse(`
 				<a class="permalink" href="#{{index $e.Tags 0}}">§</a></h3>
 		{{- end}}
 
+		{{- block "endpoint" $e}}
+		{{- $e := .}}
+		{{- $prog := prog}}
 		<div class="endpoint" id="{{$e.Method}}-{{$e.Path}}">
 			<div class="endpoint-top">
 				<code class="resource"><span class="method">{{$e.Method}}</span> {{$e.Path}}</code>
@@ -254,54 +463,124 @@ var mainTpl = template.Must(template.New("mainTpl").Funcs(funcMap).Parse(`
 				<a class="permalink" href="#{{$e.Method}}-{{$e.Path}}">§</a>
 			</div>
 			<div class="endpoint-info">
-				{{para $e.Info}}
+				{{doc $e.Info}}
 
 				{{- if $e.Request.Path}}
 					<h4>Path parameters</h4>
-					{{/* {{template "paramsTpl" $e.Request.Path}} */}}
+					<div class="params">{{(index $prog.References $e.Request.Path.Reference).Schema|schema}}</div>
 				{{- end}}
 				{{- if $e.Request.Query}}
 					<h4>Query parameters</h4>
-					{{/* {{template "paramsTpl" $e.Request.Query}} */}}
+					<div class="params">{{(index $prog.References $e.Request.Query.Reference).Schema|schema}}</div>
 				{{- end}}
 				{{- if $e.Request.Form}}
-					<h4>Form parameters</h4>
-					{{/* {{template "paramsTpl" $e.Request.Form}} */}}
+					<h4>Form parameters <sup>({{$e.Request.FormContentType}})</sup></h4>
+					<div class="params">{{(index $prog.References $e.Request.Form.Reference).Schema|schema}}</div>
+				{{- end}}
+				{{- if not $e.NoDefaultParams}}
+					{{- range $in, $p := $prog.Config.AddDefaultParams}}
+						<h4>Default {{$in}} parameters</h4>
+						<div class="params">{{(index $prog.References $p.Reference).Schema|schema}}</div>
+					{{- end}}
 				{{- end}}
-				{{- if $e.Request.Body}}
+				{{- with $e.Request.Bodies}}
 					<h4>Request body</h4>
+					{{- $main := mainCT .}}
+					{{- if gt (len .) 1}}
+					<div class="tabs">
+						{{- range $ct, $b := .}}
+							<button class="tab{{if eq $ct $main}} active{{end}}">{{$ct}}</button>
+						{{- end}}
+						{{- range $ct, $b := .}}
+							<div class="tab-content{{if eq $ct $main}} active{{end}}">
+								<a href="{{refURL $b.Reference}}">{{$b.Reference}}</a>
+								{{- if $b.Example}}{{example $b.Example}}
+								{{- else}}{{exampleFor $b.Reference}}{{end}}
+							</div>
+						{{- end}}
+					</div>
+					{{- else}}
+					{{- range $ct, $b := .}}
 					<ul>
-						<li><a href="#{{$e.Request.Body.Reference}}">{{$e.Request.Body.Reference}}</a>
-							<sup>({{$e.Request.ContentType}})</sup></li>
+						<li><a href="{{refURL $b.Reference}}">{{$b.Reference}}</a>
+							<sup>({{$ct}})</sup></li>
 					</ul>
+					{{- if $b.Example}}{{example $b.Example}}
+					{{- else}}{{exampleFor $b.Reference}}{{end}}
+					{{- end}}
+					{{- end}}
 				{{- end}}
 
 				<h4>Responses</h4>
 				<ul>{{range $code, $r := $e.Responses}}
 					<li><code class="param-name">{{$code}} {{status $code}}</code>
-						{{- if $r.Body}}
-							{{- if $r.Body.Reference}}
-								<a href="#{{$r.Body.Reference}}">{{$r.Body.Reference}}</a>
-							{{- else}}
-								{{para $r.Body.Description}}
+						{{- if gt (len $r.Bodies) 1}}
+							{{- if $r.Description}}{{doc $r.Description}}{{end}}
+							{{- $main := mainCT $r.Bodies}}
+							<div class="tabs">
+								{{- range $ct, $b := $r.Bodies}}
+									<button class="tab{{if eq $ct $main}} active{{end}}">{{$ct}}</button>
+								{{- end}}
+								{{- range $ct, $b := $r.Bodies}}
+									<div class="tab-content{{if eq $ct $main}} active{{end}}">
+										{{- if $b.Reference}}
+											<a href="{{refURL $b.Reference}}">{{$b.Reference}}</a>
+										{{- else}}
+											{{doc $b.Description}}
+										{{- end}}
+										{{- if $b.Example}}{{example $b.Example}}
+										{{- else}}{{exampleFor $b.Reference}}{{end}}
+									</div>
+								{{- end}}
+							</div>
+						{{- else}}
+						{{- range $ct, $b := $r.Bodies}}
+							{{- if $b.Reference}}
+								<a href="{{refURL $b.Reference}}">{{$b.Reference}}</a>
+							{{- else if not $r.Description}}
+								{{doc $b.Description}}
 							{{- end}}
-							<sup>({{$r.ContentType}})</sup>
+							<sup>({{$ct}})</sup>
+						{{- end}}
+						{{- if $r.Description}}{{doc $r.Description}}{{end}}
+						{{- range $ct, $b := $r.Bodies}}
+							{{- if $b.Example}}{{example $b.Example}}
+							{{- else}}{{exampleFor $b.Reference}}{{end}}
+						{{- end}}
 						{{- end}}
 					</li>
 				{{- end}}</ul>
+				{{- with samples $e}}
+				<h4>Command line</h4>
+				<div class="tabs">
+					{{- range $i, $s := .}}
+					<button class="tab{{if eq $i 0}} active{{end}}">{{$s.Label}}</button>
+					{{- end}}
+					{{- range $i, $s := .}}
+					<div class="tab-content{{if eq $i 0}} active{{end}}"><pre class="example">{{$s.Source}}</pre></div>
+					{{- end}}
+				</div>
+				{{- end}}
+				{{- if $prog.Config.HTMLConsole}}
+				{{console $e}}
+				{{- end}}
 			</div>
 		</div>
+		{{- end}}
 	{{- end}}
 
 	<h2>Models</h2>
 	{{range $k, $v := .References}}
-		<h3 id="{{$k}}">{{$k}} <a class="permalink" href="#{{$k}}">§</a></h3>
+		{{- block "model" $v}}
+		<h3 id="{{.Lookup}}">{{.Lookup}} <a class="permalink" href="#{{.Lookup}}">§</a></h3>
 		<div class="endpoint model">
-			<p class="info">{{$v.Info}}</p>
-			{{$v.Schema|schema}}
+			<div class="info">{{doc .Info}}</div>
+			{{.Schema|schema}}
 		</div>
+		{{- end}}
 	{{- end}}
 
+{{- block "footer" .}}
 	<script>
 		var add = function(endpoint) {
 			// Expand row on click.
@@ -329,6 +608,20 @@ var mainTpl = template.Must(template.New("mainTpl").Funcs(funcMap).Parse(`
 		for (var i = 0; i < ep.length; i++)
 			add(ep[i])
 
+		// Switch Content-Type tabs.
+		document.addEventListener('click', function(e) {
+			if (!e.target.classList.contains('tab'))
+				return
+
+			e.preventDefault()
+			var tabs    = e.target.parentNode.getElementsByClassName('tab'),
+			    content = e.target.parentNode.getElementsByClassName('tab-content')
+			for (var i = 0; i < tabs.length; i++) {
+				tabs[i].classList.toggle('active', tabs[i] === e.target)
+				content[i].classList.toggle('active', tabs[i] === e.target)
+			}
+		})
+
 		// Expand all rows in the section.
 		document.addEventListener('click', function(e) {
 			if (e.target.className !== 'js-expand')
@@ -343,7 +636,14 @@ var mainTpl = template.Must(template.New("mainTpl").Funcs(funcMap).Parse(`
 			for (var i = 0; i < info.length; i++)
 				info[i].style.display = info[i].style.display === 'block' ? '' : 'block'
 		})
+		{{- if .Config.HTMLConsole}}
+		{{consoleJS}}
+		{{- end}}
 	</script>
+{{- end}}
+	{{- range scripts}}
+	<script>{{.}}</script>
+	{{- end}}
 </body>
 </html>
 `))
@@ -359,7 +659,15 @@ func WriteHTML(w io.Writer, prog *docparse.Program) error {
 		}
 	}
 
-	return mainTpl.Execute(w, prog)
+	return execute(w, prog)
+}
+
+func execute(w io.Writer, prog *docparse.Program) error {
+	tpl, err := Template(prog)
+	if err != nil {
+		return err
+	}
+	return tpl.Execute(w, prog)
 }
 
 // ServeHTML serves HTML documentation at addr.
@@ -391,7 +699,7 @@ func ServeHTML(addr string) func(io.Writer, *docparse.Program) error {
 				}
 			}
 
-			err = mainTpl.Execute(w, prog)
+			err = execute(w, prog)
 			if err != nil {
 				_, wErr := fmt.Fprintf(w, "could not execute template: %v", err)
 				if wErr != nil {
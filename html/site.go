@@ -0,0 +1,247 @@
+package html
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"zgo.at/kommentaar/docparse"
+)
+
+// Pages for the html-site output; the blocks from the main template are used
+// for the content, so a custom html-template applies to these as well.
+var siteTpl = `
+{{define "site-page"}}<!DOCTYPE html>
+<html lang="en">
+<head>
+{{- template "head" prog}}
+	{{- range styles}}
+	<style>{{.}}</style>
+	{{- end}}
+	<style>
+		.nav { margin-bottom: 1em; }
+		.search { font: inherit; width: 30em; max-width: 100%; }
+		.search-results { margin-top: .5em; }
+	</style>
+</head>
+
+<body>
+	{{- if .Root}}
+		<p class="nav"><a href="{{.Root}}index.html">{{prog.Config.Title}}</a>
+		{{- with .Tag}} » {{.}}{{end}}
+		{{- with .Model}} » {{.Lookup}}{{end}}</p>
+	{{- end}}
+
+	{{- if .Model}}
+		{{- template "model" .Model}}
+	{{- else if .Tag}}
+		<h2>{{.Tag}}</h2>
+		<div>
+		{{- range .Endpoints}}
+			{{- template "endpoint" .}}
+		{{- end}}
+		</div>
+	{{- else}}
+		{{- template "header" prog}}
+
+		<input class="search" type="search" placeholder="Search endpoints and models" autofocus>
+		<ul class="search-results"></ul>
+
+		<h2>Endpoints</h2>
+		<ul>
+		{{- range .Tags}}
+			<li><a href="tag/{{pageName .}}.html">{{.}}</a></li>
+		{{- end}}
+		</ul>
+
+		<h2>Models</h2>
+		<ul>
+		{{- range $k, $v := prog.References}}
+			<li><a href="{{refURL $k}}">{{$k}}</a></li>
+		{{- end}}
+		</ul>
+
+		<script>
+			var searchIndex = {{.Search}}
+			var input   = document.getElementsByClassName('search')[0],
+			    results = document.getElementsByClassName('search-results')[0]
+			input.addEventListener('input', function() {
+				var q = input.value.toLowerCase().trim()
+				results.innerHTML = ''
+				if (q === '')
+					return
+				for (var i = 0; i < searchIndex.length; i++) {
+					var s = searchIndex[i]
+					if ((s.title + ' ' + (s.text || '')).toLowerCase().indexOf(q) === -1)
+						continue
+					var li = document.createElement('li'),
+					    a  = document.createElement('a')
+					a.href        = s.url
+					a.textContent = s.title
+					li.appendChild(a)
+					if (s.text)
+						li.appendChild(document.createTextNode(' – ' + s.text))
+					results.appendChild(li)
+				}
+			})
+		</script>
+	{{- end}}
+
+{{- template "footer" prog}}
+	{{- range scripts}}
+	<script>{{.}}</script>
+	{{- end}}
+</body>
+</html>
+{{end}}`
+
+type (
+	sitePage struct {
+		Root      string // Relative path to the root directory.
+		Tag       string
+		Endpoints []*docparse.Endpoint
+		Model     *docparse.Reference
+		Tags      []string
+		Search    []searchEntry
+	}
+
+	searchEntry struct {
+		Title string `json:"title"`
+		URL   string `json:"url"` // Relative to the root directory.
+		Text  string `json:"text,omitempty"`
+	}
+)
+
+// WriteSite writes a static HTML site to the directory in Config.OutputDir,
+// with an index, a page for every tag and model, and a search index in
+// search.json. Endpoints with more than one tag are listed on every tag's page.
+// All links are relative, so it can be opened from disk.
+//
+// Nothing is written to w.
+func WriteSite(_ io.Writer, prog *docparse.Program) error {
+	dir, err := docparse.OutputDir(prog, "html-site")
+	if err != nil {
+		return err
+	}
+
+	for i := range prog.Endpoints {
+		prog.Endpoints[i].Path = prog.Config.Basepath + prog.Config.Prefix + prog.Endpoints[i].Path
+		if len(prog.Endpoints[i].Tags) == 0 {
+			prog.Endpoints[i].Tags = []string{"default"}
+		}
+	}
+
+	byTag := make(map[string][]*docparse.Endpoint)
+	for _, e := range prog.Endpoints {
+		for _, t := range e.Tags {
+			byTag[t] = append(byTag[t], e)
+		}
+	}
+	tags := make([]string, 0, len(byTag))
+	for t := range byTag {
+		tags = append(tags, t)
+	}
+	sort.Strings(tags)
+
+	models := make([]string, 0, len(prog.References))
+	for k := range prog.References {
+		models = append(models, k)
+	}
+	if err := docparse.CheckPageNames("html-site", tags); err != nil {
+		return err
+	}
+	if err := docparse.CheckPageNames("html-site", models); err != nil {
+		return err
+	}
+
+	// Links in tag and model pages are relative to a subdirectory.
+	sub, err := siteTemplate(prog, "../")
+	if err != nil {
+		return err
+	}
+
+	for _, d := range []string{"tag", "model"} {
+		err := os.MkdirAll(filepath.Join(dir, d), 0o755)
+		if err != nil {
+			return fmt.Errorf("html-site: %v", err)
+		}
+	}
+
+	var search []searchEntry
+	for _, t := range tags {
+		page := "tag/" + docparse.PageName(t) + ".html"
+		err := writePage(sub, filepath.Join(dir, page), sitePage{Root: "../", Tag: t, Endpoints: byTag[t]})
+		if err != nil {
+			return err
+		}
+		for _, e := range byTag[t] {
+			// Endpoints are on the page of every tag, but only link to the
+			// first one in the search.
+			if e.Tags[0] != t {
+				continue
+			}
+			search = append(search, searchEntry{
+				Title: e.Method + " " + e.Path,
+				URL:   page + "#" + e.Method + "-" + e.Path,
+				Text:  e.Tagline,
+			})
+		}
+	}
+
+	for k, v := range prog.References {
+		v := v
+		page := "model/" + docparse.PageName(k) + ".html"
+		err := writePage(sub, filepath.Join(dir, page), sitePage{Root: "../", Model: &v})
+		if err != nil {
+			return err
+		}
+		search = append(search, searchEntry{Title: k, URL: page, Text: strings.SplitN(v.Info, "\n", 2)[0]})
+	}
+	sort.Slice(search, func(i, j int) bool { return search[i].URL < search[j].URL })
+
+	d, err := json.MarshalIndent(search, "", "  ")
+	if err != nil {
+		return fmt.Errorf("html-site: %v", err)
+	}
+	err = ioutil.WriteFile(filepath.Join(dir, "search.json"), d, 0o644)
+	if err != nil {
+		return fmt.Errorf("html-site: %v", err)
+	}
+
+	index, err := siteTemplate(prog, "")
+	if err != nil {
+		return err
+	}
+	return writePage(index, filepath.Join(dir, "index.html"), sitePage{Tags: tags, Search: search})
+}
+
+// Get the template for pages in the directory root relative to the site root.
+func siteTemplate(prog *docparse.Program, root string) (*template.Template, error) {
+	tpl, err := loadTemplate(prog, func(ref string) string {
+		return root + "model/" + docparse.PageName(ref) + ".html"
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tpl.Funcs(template.FuncMap{"pageName": docparse.PageName}).Parse(siteTpl)
+}
+
+func writePage(tpl *template.Template, path string, page sitePage) error {
+	fp, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("html-site: %v", err)
+	}
+	defer fp.Close()
+
+	err = tpl.ExecuteTemplate(fp, "site-page", page)
+	if err != nil {
+		return fmt.Errorf("html-site: %s: %v", path, err)
+	}
+	return fp.Close()
+}
@@ -0,0 +1,69 @@
+package html
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"zgo.at/kommentaar/docparse"
+)
+
+func TestWriteSite(t *testing.T) {
+	dir := t.TempDir()
+	prog := docparse.NewProgram(false)
+	prog.Config.Packages = []string{"../example/..."}
+	prog.Config.Output = WriteSite
+	prog.Config.OutputDir = dir
+
+	err := docparse.FindComments(os.Stdout, prog)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, f := range []string{"index.html", "search.json", "tag/foobar.html", "model/example.entity.html"} {
+		if _, err := os.Stat(filepath.Join(dir, f)); err != nil {
+			t.Error(err)
+		}
+	}
+
+	tag, err := ioutil.ReadFile(filepath.Join(dir, "tag/foobar.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(tag), `href="../model/example.RequestObj.html"`) {
+		t.Errorf("no relative link to model in tag page:\n%s", tag)
+	}
+}
+
+func TestWriteSiteTags(t *testing.T) {
+	dir := t.TempDir()
+	prog := docparse.NewProgram(false)
+	prog.Config.Packages = []string{"../testdata/site"}
+	prog.Config.Output = WriteSite
+	prog.Config.OutputDir = dir
+
+	err := docparse.FindComments(os.Stdout, prog)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tag := range []string{"bikes", "shop"} {
+		page, err := ioutil.ReadFile(filepath.Join(dir, "tag", tag+".html"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(page), "List bikes.") {
+			t.Errorf("endpoint not in %s page:\n%s", tag, page)
+		}
+	}
+
+	search, err := ioutil.ReadFile(filepath.Join(dir, "search.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(search), `"GET /bikes"`); n != 1 {
+		t.Errorf("endpoint in search index %d times:\n%s", n, search)
+	}
+}
@@ -0,0 +1,104 @@
+package html
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"io/ioutil"
+
+	"zgo.at/kommentaar/docparse"
+)
+
+// Template gets the HTML template for the program, with the html-template,
+// html-styles, and html-scripts from the configuration.
+func Template(prog *docparse.Program) (*template.Template, error) {
+	return loadTemplate(prog, func(ref string) string { return "#" + ref })
+}
+
+// Load the template; refURL gets the URL for a reference.
+func loadTemplate(prog *docparse.Program, refURL func(string) string) (*template.Template, error) {
+	tpl, err := mainTpl.Clone()
+	if err != nil {
+		return nil, err
+	}
+
+	styles := make([]template.CSS, 0, len(prog.Config.HTMLStyles))
+	for _, path := range prog.Config.HTMLStyles {
+		d, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("html-styles: %v", err)
+		}
+		styles = append(styles, template.CSS(d))
+	}
+	scripts := make([]template.JS, 0, len(prog.Config.HTMLScripts))
+	for _, path := range prog.Config.HTMLScripts {
+		d, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("html-scripts: %v", err)
+		}
+		scripts = append(scripts, template.JS(d))
+	}
+
+	render := doc(prog, refURL)
+	tpl = tpl.Funcs(template.FuncMap{
+		"doc":             render,
+		"para":            render,
+		"schema":          func(s *docparse.Schema) template.HTML { return formatSchema(prog, refURL, s) },
+		"exampleFor":      exampleFor(prog),
+		"servers":         servers(prog),
+		"prog":            func() *docparse.Program { return prog },
+		"refURL":          refURL,
+		"samples":         samples(prog),
+		"console":         console(prog),
+		"consoleSettings": consoleSettings(prog),
+		"styles":          func() []template.CSS { return styles },
+		"scripts":         func() []template.JS { return scripts },
+	})
+
+	if prog.Config.HTMLTemplate != "" {
+		d, err := ioutil.ReadFile(prog.Config.HTMLTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("html-template: %v", err)
+		}
+		// Blocks in the file replace the default ones, and any text outside of
+		// {{define}} replaces the entire template.
+		_, err = tpl.Parse(string(d))
+		if err != nil {
+			return nil, fmt.Errorf("html-template %q: %v", prog.Config.HTMLTemplate, err)
+		}
+	}
+
+	return tpl, nil
+}
+
+// CheckTemplate checks if the template from the configuration can be loaded and
+// executed, so that errors are reported before scanning the packages.
+func CheckTemplate(prog *docparse.Program) error {
+	check := &docparse.Program{
+		Config: prog.Config,
+		Endpoints: []*docparse.Endpoint{{
+			Method: "GET",
+			Path:   "/check",
+			Tags:   []string{"default"},
+			Responses: map[string]docparse.Response{"200": {Bodies: map[string]*docparse.Ref{
+				"application/json": {Reference: "check.Model", Description: "200 OK"},
+			}}},
+		}},
+		References: map[string]docparse.Reference{"check.Model": {
+			Name:    "Model",
+			Lookup:  "check.Model",
+			Context: "resp",
+			Schema:  &docparse.Schema{Type: "object"},
+		}},
+	}
+
+	tpl, err := Template(check)
+	if err != nil {
+		return err
+	}
+	err = tpl.Execute(io.Discard, check)
+	if err != nil {
+		return fmt.Errorf("html-template %q: %v", prog.Config.HTMLTemplate, err)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package html
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"zgo.at/kommentaar/docparse"
+	"zgo.at/zstd/ztest"
+)
+
+func TestTemplate(t *testing.T) {
+	tests := []struct {
+		name, tpl, want, wantErr string
+	}{
+		{"block", `{{define "footer"}}<footer>{{.Config.Title}}</footer>{{end}}`, "<footer>Test</footer>", ""},
+		{"block-endpoint", `{{define "endpoint"}}<p>{{.Method}} {{.Path}} {{len prog.Endpoints}}</p>{{end}}`, "<p>GET /check 1</p>", ""},
+		{"whole", `<title>{{.Config.Title}}</title>`, "<title>Test</title>", ""},
+		{"parse-error", `{{define "footer"}}{{.Config.Title}`, "", "bad character"},
+		{"unknown-func", `{{define "footer"}}{{nope}}{{end}}`, "", `function "nope" not defined`},
+		{"exec-error", `{{define "model"}}{{.Nope}}{{end}}`, "", "can't evaluate field Nope"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prog := docparse.NewProgram(false)
+			prog.Config.Title = "Test"
+			prog.Config.HTMLTemplate = ztest.TempFile(t, "", tt.tpl)
+			prog.Config.HTMLStyles = []string{ztest.TempFile(t, "", "body { color: red; }")}
+
+			err := CheckTemplate(prog)
+			if !ztest.ErrorContains(err, tt.wantErr) {
+				t.Fatalf("wrong error\nout:  %v\nwant: %v", err, tt.wantErr)
+			}
+			if tt.wantErr != "" {
+				return
+			}
+
+			prog.Endpoints = []*docparse.Endpoint{{Method: "GET", Path: "/check", Tags: []string{"default"}}}
+			buf := new(bytes.Buffer)
+			if err := execute(buf, prog); err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(buf.String(), tt.want) {
+				t.Errorf("%q not in output:\n%s", tt.want, buf.String())
+			}
+			if tt.name != "whole" && !strings.Contains(buf.String(), "<style>body { color: red; }</style>") {
+				t.Errorf("no style in output:\n%s", buf.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+// Package httpfile outputs a .http file for editor REST clients, such as the
+// VS Code REST Client and the JetBrains HTTP client.
+//
+// There is one request for every endpoint, separated by ###, with example
+// values for the path parameters, required query parameters, and the body. The
+// base URL is set with the {{host}} variable at the top of the file.
+package httpfile
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"zgo.at/kommentaar/docparse"
+	"zgo.at/kommentaar/snippet"
+)
+
+// Boundary for multipart/form-data bodies.
+const boundary = "kommentaar"
+
+// WriteHTTP writes a .http file.
+func WriteHTTP(w io.Writer, prog *docparse.Program) error {
+	b := new(strings.Builder)
+	fmt.Fprintf(b, "# %s\n", strings.TrimSpace(prog.Config.Title+" "+prog.Config.Version))
+	fmt.Fprintf(b, "@host = %s\n", snippet.BaseURL(prog.Config))
+
+	auth := false
+	for _, e := range prog.Endpoints {
+		if e.Auth == "basic" || (e.Auth == "" && prog.Config.Auth == "basic") {
+			auth = true
+			break
+		}
+	}
+	if auth {
+		b.WriteString("\n# Basic authentication, as base64(username:password).\n")
+		fmt.Fprintf(b, "@authorization = Basic %s\n",
+			base64.StdEncoding.EncodeToString([]byte("user:password")))
+	}
+
+	for _, e := range prog.Endpoints {
+		endpoint(b, prog, e)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func endpoint(b *strings.Builder, prog *docparse.Program, e *docparse.Endpoint) {
+	ep := *e
+	ep.Path = prog.Config.Prefix + e.Path
+	r := snippet.New(prog, &ep, "{{host}}")
+
+	b.WriteString("\n###\n")
+	if e.Tagline != "" {
+		fmt.Fprintf(b, "# %s\n", e.Tagline)
+	}
+	fmt.Fprintf(b, "%s %s\n", r.Method, r.URL)
+
+	if r.Auth == "basic" {
+		b.WriteString("Authorization: {{authorization}}\n")
+	}
+	for _, h := range r.Headers {
+		fmt.Fprintf(b, "%s: %s\n", h.Name, h.Value)
+	}
+
+	switch {
+	case r.Multipart:
+		fmt.Fprintf(b, "Content-Type: multipart/form-data; boundary=%s\n\n", boundary)
+		for _, f := range r.Form {
+			fmt.Fprintf(b, "--%s\nContent-Disposition: form-data; name=%q\n\n%s\n", boundary, f.Name, f.Value)
+		}
+		for _, f := range r.Files {
+			fmt.Fprintf(b, "--%s\nContent-Disposition: form-data; name=%q; filename=%[2]q\n\n< ./%[2]s\n",
+				boundary, f)
+		}
+		fmt.Fprintf(b, "--%s--\n", boundary)
+	case len(r.Form) > 0:
+		b.WriteString("Content-Type: application/x-www-form-urlencoded\n\n")
+		fields := make([]string, 0, len(r.Form))
+		for _, f := range r.Form {
+			fields = append(fields, url.QueryEscape(f.Name)+"="+url.QueryEscape(f.Value))
+		}
+		b.WriteString(strings.Join(fields, "\n&") + "\n")
+	case r.Body != "":
+		buf := new(bytes.Buffer)
+		if err := json.Indent(buf, []byte(r.Body), "", "    "); err != nil {
+			buf.Reset()
+			buf.WriteString(r.Body)
+		}
+		fmt.Fprintf(b, "\n%s\n", buf.String())
+	}
+}
@@ -0,0 +1,12 @@
+package httpfile_test
+
+import (
+	"testing"
+
+	"zgo.at/kommentaar/httpfile"
+	"zgo.at/kommentaar/internal/golden"
+)
+
+func TestWriteHTTP(t *testing.T) {
+	golden.Test(t, "../testdata/http/src", "want.http", httpfile.WriteHTTP, nil)
+}
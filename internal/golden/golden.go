@@ -0,0 +1,65 @@
+// Package golden runs tests that compare the output for a directory of packages
+// with a file.
+package golden
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+
+	"zgo.at/kommentaar/docparse"
+	"zgo.at/kommentaar/kconfig"
+	"zgo.at/zstd/ztest"
+)
+
+// Test runs a subtest for every package in dir, and compares the output with
+// the want file in that package. The package's test.conf is loaded if it
+// exists.
+//
+// If check isn't nil it's called with the path and output for additional
+// checks.
+func Test(
+	t *testing.T, dir, want string,
+	output func(io.Writer, *docparse.Program) error,
+	check func(t *testing.T, path string, out []byte),
+) {
+	t.Helper()
+
+	tests, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name(), func(t *testing.T) {
+			path := dir + "/" + tt.Name()
+
+			prog := docparse.NewProgram(false)
+			prog.Config.Title = "x"
+			prog.Config.Version = "x"
+			prog.Config.Packages = []string{path}
+			prog.Config.Output = output
+			prog.Config.StructTag = "json"
+
+			if _, err := os.Stat(path + "/test.conf"); err == nil {
+				if err := kconfig.Load(prog, path+"/test.conf"); err != nil {
+					t.Fatalf("test.conf: %v", err)
+				}
+			}
+
+			out := new(bytes.Buffer)
+			err := docparse.FindComments(out, prog)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if d := ztest.Diff(out.String(), string(ztest.Read(t, path+"/"+want))); d != "" {
+				t.Error(d)
+			}
+			if check != nil {
+				check(t, path, out.Bytes())
+			}
+		})
+	}
+}
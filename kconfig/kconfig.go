@@ -2,16 +2,24 @@
 package kconfig
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
-	"strconv"
+	"path/filepath"
 	"strings"
 
 	"zgo.at/kommentaar/docparse"
+	"zgo.at/kommentaar/goclient"
 	"zgo.at/kommentaar/html"
+	"zgo.at/kommentaar/httpfile"
+	"zgo.at/kommentaar/markdown"
 	"zgo.at/kommentaar/openapi2"
+	"zgo.at/kommentaar/postman"
+	"zgo.at/kommentaar/snippet"
+	"zgo.at/kommentaar/typescript"
 	"zgo.at/kommentaar/zgo"
 	"zgo.at/sconfig"
+	"zgo.at/zstd/zstring"
 	_ "zgo.at/sconfig/handlers/html/template" // template.HTML handler
 )
 
@@ -30,7 +38,7 @@ func Load(prog *docparse.Program, file string) error {
 
 		"DefaultResponse": func(line []string) error {
 			if prog.Config.DefaultResponse == nil {
-				prog.Config.DefaultResponse = make(map[int]docparse.Response)
+				prog.Config.DefaultResponse = make(map[string]docparse.Response)
 			}
 
 			code, resp, err := docparse.ParseResponse(prog, "", "Response "+strings.Join(line, " "))
@@ -49,28 +57,134 @@ func Load(prog *docparse.Program, file string) error {
 			return nil
 		},
 
+		// The map target can be a Go predeclared type, a struct reference, or
+		// an inline JSON schema. The JSON schema may contain spaces, so we
+		// can't use the default map[string]string handler.
+		"MapTypes": func(line []string) error {
+			if prog.Config.MapTypes == nil {
+				prog.Config.MapTypes = make(map[string]string)
+			}
+
+			for i := 0; i < len(line); i++ {
+				k := line[i]
+				i++
+				if i >= len(line) {
+					return fmt.Errorf("no target for %q", k)
+				}
+
+				v := line[i]
+				if strings.HasPrefix(v, "{") {
+					for depth := strings.Count(v, "{") - strings.Count(v, "}"); depth > 0; {
+						i++
+						if i >= len(line) {
+							return fmt.Errorf("unterminated JSON schema for %q: %q", k, v)
+						}
+						v += " " + line[i]
+						depth += strings.Count(line[i], "{") - strings.Count(line[i], "}")
+					}
+
+					var s docparse.Schema
+					if err := json.Unmarshal([]byte(v), &s); err != nil {
+						return fmt.Errorf("invalid JSON schema for %q: %v", k, err)
+					}
+				} else if !zgo.PredeclaredType(v) && !strings.Contains(v, ".") {
+					return fmt.Errorf("'%s %s' is not a predeclared type, struct reference, or JSON schema",
+						k, v)
+				}
+
+				prog.Config.MapTypes[k] = v
+			}
+			return nil
+		},
+
 		"AddDefaultResponse": func(line []string) error {
 			for _, c := range line {
 				c = strings.TrimSpace(c)
 				if c != "" {
-					i, err := strconv.ParseInt(c, 10, 32)
+					code, err := docparse.ParseResponseCode(c)
 					if err != nil {
 						return err
 					}
-					prog.Config.AddDefaultResponse = append(prog.Config.AddDefaultResponse, int(i))
+					prog.Config.AddDefaultResponse = append(prog.Config.AddDefaultResponse, code)
 				}
 			}
 			return nil
 		},
+
+		"AddDefaultParams": func(line []string) error {
+			if prog.Config.AddDefaultParams == nil {
+				prog.Config.AddDefaultParams = make(map[string]*docparse.Ref)
+			}
+
+			for _, p := range line {
+				in, ref, err := docparse.ParseDefaultParam(prog, "", p)
+				if err != nil {
+					return err
+				}
+				if _, ok := prog.Config.AddDefaultParams[in]; ok {
+					return fmt.Errorf("default params for %v defined more than once", in)
+				}
+				prog.Config.AddDefaultParams[in] = ref
+			}
+			return nil
+		},
+
+		"Servers": func(line []string) error {
+			s, err := docparse.ParseServer(line)
+			if err != nil {
+				return err
+			}
+			prog.Config.Servers = append(prog.Config.Servers, s)
+			return nil
+		},
 	})
 	if err != nil {
 		return fmt.Errorf("could not load config: %v", err)
 	}
 
-	// Validate that MapType is a Go primitive.
-	for k, v := range prog.Config.MapTypes {
-		if !zgo.PredeclaredType(v) {
-			return fmt.Errorf("map-type '%s %s' is not a predeclared type", k, v)
+	for _, s := range prog.Config.Schemes {
+		if !zstring.Contains([]string{"http", "https", "ws", "wss"}, s) {
+			return fmt.Errorf("invalid scheme %q; must be one of http, https, ws, wss", s)
+		}
+	}
+
+	for _, s := range prog.Config.CodeSamples {
+		if _, ok := snippet.Get(s); !ok {
+			return fmt.Errorf("invalid code-samples %q; must be one of %s",
+				s, strings.Join(snippet.Names(), ", "))
+		}
+	}
+
+	if prog.Config.CollectionFormat != "" && !zstring.Contains(docparse.CollectionFormats, prog.Config.CollectionFormat) {
+		return fmt.Errorf("invalid collection-format %q; must be one of %s",
+			prog.Config.CollectionFormat, strings.Join(docparse.CollectionFormats, ", "))
+	}
+
+	// Schema files are relative to the config file.
+	for k, v := range prog.Config.MapSchemas {
+		if !filepath.IsAbs(v) {
+			prog.Config.MapSchemas[k] = filepath.Join(filepath.Dir(file), v)
+		}
+	}
+
+	// HTML files are relative to the config file, too.
+	rel := func(path string) string {
+		if path == "" || filepath.IsAbs(path) {
+			return path
+		}
+		return filepath.Join(filepath.Dir(file), path)
+	}
+	prog.Config.HTMLTemplate = rel(prog.Config.HTMLTemplate)
+	for i := range prog.Config.HTMLStyles {
+		prog.Config.HTMLStyles[i] = rel(prog.Config.HTMLStyles[i])
+	}
+	for i := range prog.Config.HTMLScripts {
+		prog.Config.HTMLScripts[i] = rel(prog.Config.HTMLScripts[i])
+	}
+	if prog.Config.HTMLTemplate != "" || len(prog.Config.HTMLStyles) > 0 || len(prog.Config.HTMLScripts) > 0 {
+		err := html.CheckTemplate(prog)
+		if err != nil {
+			return err
 		}
 	}
 
@@ -100,6 +214,20 @@ func Output(out, addr string) (func(io.Writer, *docparse.Program) error, error)
 		} else {
 			outFunc = html.WriteHTML
 		}
+	case "html-site":
+		outFunc = html.WriteSite
+	case "markdown":
+		outFunc = markdown.WriteMarkdown
+	case "markdown-split":
+		outFunc = markdown.WriteSplit
+	case "postman":
+		outFunc = postman.WritePostman
+	case "http":
+		outFunc = httpfile.WriteHTTP
+	case "typescript":
+		outFunc = typescript.WriteTypeScript
+	case "goclient":
+		outFunc = goclient.WriteClient
 	default:
 		return nil, fmt.Errorf("unknown value: %q", out)
 	}
@@ -17,6 +17,23 @@ func TestLoad(t *testing.T) {
 			default-response 400: zgo.at/kommentaar/docparse.Param
 			default-response 404 (application/json): net/mail.Address
 		`))},
+		{"map-types", []byte(ztest.NormalizeIndent(`
+			map-types
+				sql.NullString  string
+				null.Time       {"type": "string", "format": "date-time"}
+				money.Amount    net/mail.Address
+		`))},
+		{"collection-format", []byte("collection-format multi\n")},
+		{"add-default-response", []byte("add-default-response 400 5XX default\n")},
+		{"servers", []byte(ztest.NormalizeIndent(`
+			host     api.example.com
+			schemes  https http
+			consumes application/json application/xml
+			server   https://api.example.com/v1 Production
+			server   https://{region}.example.com/{version} region=eu,us version=v1 Regional
+		`))},
+		{"add-default-params", []byte("add-default-params query:net/mail.Address header:net/http.Cookie\n")},
+		{"code-samples", []byte("code-samples curl httpie\n")},
 	}
 
 	for _, tt := range tests {
@@ -41,7 +41,17 @@ func start() (bool, error) {
 	openapi2-json        OpenAPI/Swagger 2.0 as JSON
 	openapi2-jsonindent  OpenAPI/Swagger 2.0 as JSON indented
 	html                 HTML documentation
+	html-site            HTML documentation as a directory with a page for
+	                     every tag and model; requires -output-dir
+	markdown             GitHub-flavoured Markdown documentation
+	markdown-split       Markdown documentation as a directory with a file
+	                     for every tag; requires -output-dir
+	postman              Postman Collection v2.1
+	http                 .http file for the VS Code and JetBrains REST clients
+	typescript           TypeScript type definitions
+	goclient             Go HTTP client package
 `)
+	outputDir := flag.String("output-dir", "", "directory to write to, for outputs that write more than one file")
 	cpuprofile := flag.String("cpuprofile", "", "write cpu profile to `file`")
 	memprofile := flag.String("memprofile", "", "write memory profile to `file`")
 
@@ -79,6 +89,10 @@ func start() (bool, error) {
 		}
 	}
 
+	if *outputDir != "" {
+		prog.Config.OutputDir = *outputDir
+	}
+
 	pkgs := flag.Args()
 	if len(pkgs) > 0 {
 		prog.Config.Packages = pkgs
@@ -3,6 +3,7 @@ package main
 import (
 	"bytes"
 	"flag"
+	"fmt"
 	"go/build"
 	"io/ioutil"
 	"os"
@@ -10,6 +11,7 @@ import (
 	"strings"
 	"testing"
 
+	"gopkg.in/yaml.v3"
 	"zgo.at/kommentaar/docparse"
 	"zgo.at/kommentaar/kconfig"
 	"zgo.at/kommentaar/openapi2"
@@ -38,11 +40,10 @@ func TestOpenAPI2(t *testing.T) {
 		t.Run(tt.Name(), func(t *testing.T) {
 			path := "./testdata/openapi2/src/" + tt.Name()
 
-			want, err := ioutil.ReadFile(path + "/want.json")
+			want, err := ioutil.ReadFile(path + "/want.yaml")
 			if err != nil && !os.IsNotExist(err) {
 				t.Fatalf("could not read output: %v", err)
 			}
-			want = append(bytes.TrimSpace(want), '\n')
 
 			wantJSON, err := ioutil.ReadFile(path + "/want.json")
 			if err != nil && !os.IsNotExist(err) {
@@ -79,9 +80,13 @@ func TestOpenAPI2(t *testing.T) {
 			if !ztest.ErrorContains(err, string(wantErr)) {
 				t.Fatalf("wrong error\nout:  %v\nwant: %v", err, string(wantErr))
 			}
-			out := strings.TrimSpace(outBuf.String()) + "\n"
+			if len(wantErr) > 0 {
+				return
+			}
 
-			d := ztest.Diff(string(want), out)
+			// There is no YAML output, so compare the data rather than the
+			// formatting; the JSON output is valid YAML.
+			d := ztest.Diff(normalizeYAML(t, outBuf.Bytes()), normalizeYAML(t, want))
 			if d != "" {
 				t.Fatalf("wrong output\n%v", d)
 			}
@@ -105,3 +110,54 @@ func TestOpenAPI2(t *testing.T) {
 		})
 	}
 }
+
+// Decode YAML (or JSON) and encode it again, so that the formatting and key
+// order don't matter.
+func normalizeYAML(t *testing.T, in []byte) string {
+	t.Helper()
+
+	var v any
+	if err := yaml.Unmarshal(in, &v); err != nil {
+		t.Fatalf("normalizeYAML: %v", err)
+	}
+	v = stringKeys(v)
+
+	// The YAML goldens don't include the empty contact.
+	if m, ok := v.(map[string]any); ok {
+		if info, ok := m["info"].(map[string]any); ok {
+			if c, ok := info["contact"].(map[string]any); ok && len(c) == 0 {
+				delete(info, "contact")
+			}
+		}
+	}
+
+	out, err := yaml.Marshal(v)
+	if err != nil {
+		t.Fatalf("normalizeYAML: %v", err)
+	}
+	return string(out)
+}
+
+// Convert all map keys to strings; response codes are integers in YAML.
+func stringKeys(v any) any {
+	switch vv := v.(type) {
+	case map[string]any:
+		for k, c := range vv {
+			vv[k] = stringKeys(c)
+		}
+		return vv
+	case map[any]any:
+		m := make(map[string]any, len(vv))
+		for k, c := range vv {
+			m[fmt.Sprint(k)] = stringKeys(c)
+		}
+		return m
+	case []any:
+		for i, c := range vv {
+			vv[i] = stringKeys(c)
+		}
+		return vv
+	default:
+		return v
+	}
+}
@@ -0,0 +1,369 @@
+// Package markdown outputs the documentation as GitHub-flavoured Markdown.
+//
+// Endpoints are grouped by their first tag, with tables for the parameters and
+// responses. All request and response bodies are listed in the models section,
+// with an anchor for every model.
+package markdown
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"zgo.at/kommentaar/docparse"
+	"zgo.at/zstd/zstring"
+)
+
+// WriteMarkdown writes the documentation as a single Markdown file.
+func WriteMarkdown(w io.Writer, prog *docparse.Program) error {
+	m := writer{prog: prog}
+	tags, byTag := groupTags(prog)
+
+	m.header()
+	m.printf("## Endpoints\n\n")
+	for _, t := range tags {
+		m.printf("### %s\n\n", t)
+		for _, e := range byTag[t] {
+			m.endpoint(e, "####")
+		}
+	}
+	m.printf("## Models\n\n")
+	m.models("###")
+
+	_, err := io.WriteString(w, strings.TrimRight(m.b.String(), "\n")+"\n")
+	return err
+}
+
+// WriteSplit writes the documentation to the directory in Config.OutputDir,
+// with an index.md, a file for every tag, and a models.md.
+//
+// Nothing is written to w.
+func WriteSplit(_ io.Writer, prog *docparse.Program) error {
+	dir, err := docparse.OutputDir(prog, "markdown-split")
+	if err != nil {
+		return err
+	}
+
+	tags, byTag := groupTags(prog)
+	if err := docparse.CheckPageNames("markdown-split", tags); err != nil {
+		return err
+	}
+
+	index := writer{prog: prog}
+	index.header()
+	index.printf("## Endpoints\n\n")
+	for _, t := range tags {
+		index.printf("- [%s](%s.md)\n", t, docparse.PageName(t))
+	}
+	index.printf("\n[Models](models.md)\n")
+	if err := index.write(filepath.Join(dir, "index.md")); err != nil {
+		return err
+	}
+
+	for _, t := range tags {
+		m := writer{prog: prog, modelFile: "models.md"}
+		m.printf("# %s\n\n", t)
+		for _, e := range byTag[t] {
+			m.endpoint(e, "##")
+		}
+		if err := m.write(filepath.Join(dir, docparse.PageName(t)+".md")); err != nil {
+			return err
+		}
+	}
+
+	m := writer{prog: prog}
+	m.printf("# Models\n\n")
+	m.models("##")
+	return m.write(filepath.Join(dir, "models.md"))
+}
+
+// Group endpoints by their first tag; endpoints without tags are added to
+// "default".
+func groupTags(prog *docparse.Program) ([]string, map[string][]*docparse.Endpoint) {
+	byTag := make(map[string][]*docparse.Endpoint)
+	for _, e := range prog.Endpoints {
+		t := "default"
+		if len(e.Tags) > 0 {
+			t = e.Tags[0]
+		}
+		byTag[t] = append(byTag[t], e)
+	}
+
+	tags := make([]string, 0, len(byTag))
+	for t := range byTag {
+		tags = append(tags, t)
+	}
+	sort.Strings(tags)
+	return tags, byTag
+}
+
+type writer struct {
+	prog      *docparse.Program
+	modelFile string // File with the models; blank for the current file.
+	b         strings.Builder
+}
+
+func (m *writer) printf(format string, a ...any) { fmt.Fprintf(&m.b, format, a...) }
+
+func (m *writer) write(path string) error {
+	err := os.WriteFile(path, []byte(strings.TrimRight(m.b.String(), "\n")+"\n"), 0o644)
+	if err != nil {
+		return fmt.Errorf("markdown-split: %v", err)
+	}
+	return nil
+}
+
+// Link to a model.
+func (m *writer) link(lookup string) string {
+	return fmt.Sprintf("[%s](%s#%s)", lookup, m.modelFile, docparse.PageName(lookup))
+}
+
+func (m *writer) header() {
+	c := m.prog.Config
+	m.printf("# %s\n\n", c.Title)
+	if c.Version != "" {
+		m.printf("Version %s\n\n", c.Version)
+	}
+	if c.Description != "" {
+		m.printf("%s\n\n", strings.TrimSpace(string(c.Description)))
+	}
+	if c.ContactName != "" || c.ContactEmail != "" || c.ContactSite != "" {
+		m.printf("Contact: %s\n\n", strings.Join(zstring.Filter(
+			[]string{c.ContactName, c.ContactEmail, c.ContactSite}, zstring.FilterEmpty), ", "))
+	}
+
+	// The paths already include the Basepath.
+	c.Basepath = ""
+	if s := c.AllServers(); len(s) > 0 {
+		m.printf("Base URLs:\n\n")
+		for _, srv := range s {
+			if srv.Description != "" {
+				m.printf("- `%s` – %s\n", srv.URL, srv.Description)
+			} else {
+				m.printf("- `%s`\n", srv.URL)
+			}
+		}
+		m.printf("\n")
+	}
+}
+
+func (m *writer) endpoint(e *docparse.Endpoint, h string) {
+	c := m.prog.Config
+	m.printf("%s `%s %s`\n\n", h, e.Method, c.Basepath+c.Prefix+e.Path)
+	if e.Tagline != "" {
+		m.printf("%s\n\n", e.Tagline)
+	}
+	if e.Info != "" {
+		m.printf("%s\n\n", strings.TrimSpace(docparse.Markdown(m.prog, e.Info)))
+	}
+
+	if e.Request.Path != nil {
+		m.params("Path parameters", e.Request.Path, true)
+	}
+	if e.Request.Query != nil {
+		m.params("Query parameters", e.Request.Query, false)
+	}
+	if e.Request.Form != nil {
+		m.params("Form parameters", e.Request.Form, false)
+	}
+	if len(e.Request.Bodies) > 0 {
+		var (
+			body []string
+			ex   json.RawMessage
+		)
+		for _, ct := range docparse.ContentTypes(e.Request.Bodies) {
+			b := e.Request.Bodies[ct]
+			l := b.Description
+			if b.Reference != "" {
+				l = m.link(b.Reference)
+			}
+			body = append(body, fmt.Sprintf("%s (`%s`)", l, ct))
+			if b.Example != nil {
+				ex = b.Example
+			}
+		}
+		m.printf("**Request body**: %s\n\n", strings.Join(body, ", "))
+		if ex != nil {
+			m.example(ex)
+		}
+	}
+
+	if len(e.Responses) == 0 {
+		return
+	}
+	codes := make([]string, 0, len(e.Responses))
+	for c := range e.Responses {
+		codes = append(codes, c)
+	}
+	sort.Strings(codes)
+
+	m.printf("**Responses**\n\n")
+	m.printf("| Code | Description | Body |\n")
+	m.printf("| ---- | ----------- | ---- |\n")
+	for _, code := range codes {
+		r := e.Responses[code]
+		var (
+			desc string
+			body []string
+		)
+		for _, ct := range docparse.ContentTypes(r.Bodies) {
+			b := r.Bodies[ct]
+			if b.Reference != "" {
+				body = append(body, fmt.Sprintf("%s (`%s`)", m.link(b.Reference), ct))
+			} else if desc == "" {
+				desc = b.Description // "200 OK (no data)" etc.
+			}
+		}
+		switch {
+		case r.Description != "":
+			desc = docparse.Markdown(m.prog, r.Description)
+		case desc == "":
+			desc = docparse.StatusText(code)
+		}
+
+		m.printf("| %s | %s | %s |\n", code, cell(desc), strings.Join(body, "<br>"))
+	}
+	m.printf("\n")
+
+	for _, code := range codes {
+		r := e.Responses[code]
+		for _, ct := range docparse.ContentTypes(r.Bodies) {
+			if ex := r.Bodies[ct].Example; ex != nil {
+				m.printf("Example %s response:\n\n", code)
+				m.example(ex)
+			}
+		}
+	}
+}
+
+func (m *writer) example(ex json.RawMessage) {
+	b := new(bytes.Buffer)
+	if err := json.Indent(b, ex, "", "    "); err != nil {
+		b.Reset()
+		b.Write(ex)
+	}
+	m.printf("```json\n%s\n```\n\n", b.String())
+}
+
+// Parameters table; path parameters are always required.
+func (m *writer) params(title string, r *docparse.Ref, required bool) {
+	ref, ok := m.prog.References[r.Reference]
+	if !ok || ref.Schema == nil {
+		return
+	}
+	m.printf("**%s**\n\n", title)
+	m.properties(ref.Schema, required)
+}
+
+// Models section; path, query, and form parameters are already listed with the
+// endpoints.
+func (m *writer) models(h string) {
+	lookups := make([]string, 0, len(m.prog.References))
+	for k, v := range m.prog.References {
+		if zstring.Contains([]string{"path", "query", "form", "header"}, v.Context) {
+			continue
+		}
+		lookups = append(lookups, k)
+	}
+	sort.Strings(lookups)
+
+	for _, k := range lookups {
+		ref := m.prog.References[k]
+		m.printf("<a id=\"%s\"></a>\n\n%s %s\n\n", docparse.PageName(k), h, k)
+		if ref.Info != "" {
+			m.printf("%s\n\n", strings.TrimSpace(docparse.Markdown(m.prog, ref.Info)))
+		}
+		if ref.Schema == nil {
+			continue
+		}
+		if ref.Schema.Type != "object" {
+			m.printf("Type: %s\n\n", m.typ(ref.Schema))
+			continue
+		}
+		m.properties(ref.Schema, false)
+	}
+}
+
+// Table with the properties of an object; all properties are marked as
+// required if allRequired is set.
+func (m *writer) properties(s *docparse.Schema, allRequired bool) {
+	if len(s.Properties) == 0 {
+		m.printf("No properties.\n\n")
+		return
+	}
+
+	m.printf("| Name | Type | Format | Required | Enum | Range | Default | Description |\n")
+	m.printf("| ---- | ---- | ------ | -------- | ---- | ----- | ------- | ----------- |\n")
+	for _, name := range s.PropertyOrder {
+		p := s.Properties[name]
+		if p == nil || p.OmitDoc {
+			continue
+		}
+
+		// Path, query, and form parameters have required on the property.
+		required := ""
+		if allRequired || zstring.Contains(s.Required, name) || zstring.Contains(p.Required, name) {
+			required = "yes"
+		}
+
+		enum := make([]string, 0, len(p.Enum))
+		for _, v := range p.Enum {
+			enum = append(enum, jsonCode(v))
+		}
+		rng := ""
+		switch {
+		case p.Minimum != 0 && p.Maximum != 0:
+			rng = fmt.Sprintf("%d–%d", p.Minimum, p.Maximum)
+		case p.Minimum != 0:
+			rng = fmt.Sprintf("≥ %d", p.Minimum)
+		case p.Maximum != 0:
+			rng = fmt.Sprintf("≤ %d", p.Maximum)
+		}
+		def := ""
+		if p.Default != nil {
+			def = jsonCode(p.Default)
+		}
+
+		m.printf("| `%s` | %s | %s | %s | %s | %s | %s | %s |\n",
+			name, m.typ(p), p.Format, required, cell(strings.Join(enum, ", ")), rng, cell(def),
+			cell(docparse.Markdown(m.prog, p.Description)))
+	}
+	m.printf("\n")
+}
+
+// Describe the type of the schema, linking to references.
+func (m *writer) typ(s *docparse.Schema) string {
+	var t string
+	switch {
+	case s.Reference != "":
+		t = m.link(s.Reference)
+	case s.Type == "array" && s.Items != nil:
+		t = "array of " + m.typ(s.Items)
+	case s.Type == "object" && s.AdditionalProperties != nil:
+		t = "map of " + m.typ(s.AdditionalProperties)
+	default:
+		t = s.Type
+	}
+	if s.Nullable {
+		t += ", nullable"
+	}
+	return t
+}
+
+func jsonCode(v any) string {
+	j, _ := json.Marshal(v)
+	return "`" + string(j) + "`"
+}
+
+// Make a string fit in a table cell.
+func cell(s string) string {
+	s = strings.TrimSpace(s)
+	s = strings.ReplaceAll(s, "|", `\|`)
+	s = strings.ReplaceAll(s, "\n\n", "<br><br>")
+	return strings.ReplaceAll(s, "\n", " ")
+}
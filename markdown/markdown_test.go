@@ -0,0 +1,93 @@
+package markdown
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"zgo.at/kommentaar/docparse"
+)
+
+func TestMarkdown(t *testing.T) {
+	prog := docparse.NewProgram(false)
+	prog.Config.Packages = []string{"../example/..."}
+	prog.Config.Output = WriteMarkdown
+	prog.Config.Title = "Example"
+
+	w := new(bytes.Buffer)
+	err := docparse.FindComments(w, prog)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := w.String()
+	for _, want := range []string{
+		"# Example\n",
+		"### foobar\n",
+		"#### `DELETE /entities/{id}.json`\n",
+		"| `id` | integer |  | yes |  |  |  | The id to delete |\n",
+		"| 200 | OK | [example.entityResponse](#example.entityResponse) (`application/json`) |\n",
+		`<a id="example.entity"></a>`,
+		"| `Name` | string |  | yes |  |  |  | Name of the entity. |\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("%q not in output:\n%s", want, out)
+		}
+	}
+}
+
+func TestWriteSplit(t *testing.T) {
+	dir := t.TempDir()
+	prog := docparse.NewProgram(false)
+	prog.Config.Packages = []string{"../example/..."}
+	prog.Config.Output = WriteSplit
+	prog.Config.OutputDir = dir
+
+	err := docparse.FindComments(os.Stdout, prog)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, f := range []string{"index.md", "models.md", "default.md", "foobar.md"} {
+		if _, err := os.Stat(filepath.Join(dir, f)); err != nil {
+			t.Error(err)
+		}
+	}
+
+	tag, err := os.ReadFile(filepath.Join(dir, "foobar.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(tag), "(models.md#example.RequestObj)") {
+		t.Errorf("no link to models.md in tag page:\n%s", tag)
+	}
+}
+
+func TestContentTypes(t *testing.T) {
+	prog := docparse.NewProgram(false)
+	prog.Config.Packages = []string{"../testdata/content-types"}
+	prog.Config.Output = WriteMarkdown
+	prog.Config.Title = "Example"
+	prog.Config.StructTag = "json"
+
+	w := new(bytes.Buffer)
+	err := docparse.FindComments(w, prog)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := w.String()
+	for _, want := range []string{
+		"**Request body**: [content-types.report](#content-types.report) (`application/json`), [content-types.report](#content-types.report) (`application/xml`)\n",
+		"| 200 | 200 OK (text/csv data) | [content-types.report](#content-types.report) (`application/json`) |\n",
+		"| `page` | integer |  |  |  | ≥ 1 |  |  |\n",
+		"| `limit` | integer |  |  |  | ≤ 100 |  |  |\n",
+		"| `year` | integer |  |  |  | 2000–2099 |  |  |\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("%q not in output:\n%s", want, out)
+		}
+	}
+}
@@ -8,10 +8,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net/url"
 	"sort"
 	"strings"
 
 	"zgo.at/kommentaar/docparse"
+	"zgo.at/kommentaar/snippet"
 	"zgo.at/kommentaar/zgo"
 )
 
@@ -25,7 +27,6 @@ type (
 		SecurityDefinitions map[string]any   `json:"securityDefinitions,omitempty"`
 		Security            []map[string]any `json:"security,omitempty"`
 
-		// TODO: do we need this? will have to come from config
 		Host     string   `json:"host,omitempty"`
 		BasePath string   `json:"basePath,omitempty"`
 		Schemes  []string `json:"schemes,omitempty"`
@@ -35,6 +36,7 @@ type (
 		Tags        []Tag                      `json:"tags,omitempty"`
 		Paths       map[string]*Path           `json:"paths"`
 		Definitions map[string]docparse.Schema `json:"definitions"`
+		Parameters  map[string]Parameter       `json:"parameters,omitempty"`
 	}
 
 	// Info provides metadata about the API.
@@ -54,19 +56,27 @@ type (
 
 	// Parameter describes a single operation parameter.
 	Parameter struct {
-		Name        string           `json:"name"`
-		In          string           `json:"in"` // query, header, path, cookie
+		Ref         string           `json:"$ref,omitempty"` // Reference to the top-level parameters.
+		Name        string           `json:"name,omitempty"`
+		In          string           `json:"in,omitempty"` // query, header, path, cookie
 		Description string           `json:"description,omitempty"`
 		Type        string           `json:"type,omitempty"`
 		Items       *docparse.Schema `json:"items,omitempty"`
 		Format      string           `json:"format,omitempty"`
 		Required    bool             `json:"required,omitempty"`
 		Readonly    *bool            `json:"readOnly,omitempty"`
-		Enum        []string         `json:"enum,omitempty"`
-		Default     string           `json:"default,omitempty"`
+		Enum        []any            `json:"enum,omitempty"`
+		Default     any              `json:"default,omitempty"`
 		Minimum     int              `json:"minimum,omitempty"`
 		Maximum     int              `json:"maximum,omitempty"`
 		Schema      *docparse.Schema `json:"schema,omitempty"`
+
+		CollectionFormat string `json:"collectionFormat,omitempty"`
+
+		// OpenAPI 2 only allows examples in schemas and responses; these
+		// extensions are supported by most tools.
+		Example  any                        `json:"x-example,omitempty"`
+		Examples map[string]json.RawMessage `json:"x-examples,omitempty"`
 	}
 
 	// Tag adds metadata to a single tag that is used by the Operation type.
@@ -87,14 +97,19 @@ type (
 
 	// Operation describes a single API operation on a path.
 	Operation struct {
-		OperationID string           `json:"operationId"`
-		Tags        []string         `json:"tags,omitempty"`
-		Summary     string           `json:"summary,omitempty"`
-		Description string           `json:"description,omitempty"`
-		Consumes    []string         `json:"consumes,omitempty"`
-		Produces    []string         `json:"produces,omitempty"`
-		Parameters  []Parameter      `json:"parameters,omitempty"`
-		Responses   map[int]Response `json:"responses"`
+		OperationID string              `json:"operationId"`
+		Tags        []string            `json:"tags,omitempty"`
+		Summary     string              `json:"summary,omitempty"`
+		Description string              `json:"description,omitempty"`
+		Consumes    []string            `json:"consumes,omitempty"`
+		Produces    []string            `json:"produces,omitempty"`
+		Parameters  []Parameter         `json:"parameters,omitempty"`
+		Responses   map[string]Response `json:"responses"`
+		CodeSamples []snippet.Sample    `json:"x-code-samples,omitempty"`
+
+		// Pointer so that we can output an empty list to disable
+		// authentication for an operation.
+		Security *[]map[string]any `json:"security,omitempty"`
 	}
 
 	// Reference other components in the specification, internally and
@@ -105,8 +120,9 @@ type (
 
 	// Response describes a single response from an API Operation.
 	Response struct {
-		Description string           `json:"description,omitempty"`
-		Schema      *docparse.Schema `json:"schema,omitempty"`
+		Description string                     `json:"description,omitempty"`
+		Schema      *docparse.Schema           `json:"schema,omitempty"`
+		Examples    map[string]json.RawMessage `json:"examples,omitempty"`
 	}
 )
 
@@ -158,16 +174,42 @@ func write(outFormat string, w io.Writer, prog *docparse.Program) error {
 				URL:   prog.Config.ContactSite,
 			},
 		},
-		Consumes:    []string{prog.Config.DefaultRequestCt},
-		Produces:    []string{prog.Config.DefaultRequestCt},
+		Host:        prog.Config.Host,
+		Schemes:     prog.Config.Schemes,
+		Consumes:    prog.Config.Consumes,
+		Produces:    prog.Config.Produces,
 		Paths:       map[string]*Path{},
 		Definitions: map[string]docparse.Schema{},
 	}
+	if len(out.Consumes) == 0 {
+		out.Consumes = []string{prog.Config.DefaultRequestCt}
+	}
+	if len(out.Produces) == 0 {
+		out.Produces = []string{prog.Config.DefaultResponseCt}
+	}
+
+	// OpenAPI 2 only supports a single server, without variables; use the
+	// first one if there's no host.
+	if out.Host == "" && len(prog.Config.Servers) > 0 {
+		u, err := url.Parse(prog.Config.Servers[0].Expand())
+		if err != nil {
+			return fmt.Errorf("server URL: %v", err)
+		}
+		out.Host = u.Host
+		if u.Scheme != "" {
+			out.Schemes = []string{u.Scheme}
+		}
+		if out.BasePath == "" && u.Path != "" && u.Path != "/" {
+			out.BasePath = u.Path
+		}
+	}
 
 	// Auth info
 	switch prog.Config.Auth {
 	default:
 		return fmt.Errorf("unknown auth value: %q", prog.Config.Auth)
+	case "":
+		// No authentication.
 	case "basic":
 		out.SecurityDefinitions = map[string]any{
 			"basicAuth": map[string]any{"type": "basic"},
@@ -183,16 +225,45 @@ func write(outFormat string, w io.Writer, prog *docparse.Program) error {
 			return fmt.Errorf("schema is nil for %q", k)
 		}
 		switch v.Context {
-		case "form", "query", "path":
+		case "form", "query", "path", "header":
 			// Nothing, this will be inline in the operation.
 		default:
 			if !v.IsEmbed {
-				prefixPropertyReferences(v.Schema.Properties)
-				out.Definitions[k] = *v.Schema
+				// Don't modify the schema in prog.References.
+				schema := copySchema(v.Schema)
+				markdownDescriptions(prog, schema)
+				prefixPropertyReferences(schema.Properties)
+				prefixAllOfReferences(schema.AllOf)
+				nullableReferences(schema)
+				out.Definitions[k] = *schema
 			}
 		}
 	}
 
+	// Add default parameters; these are defined once and referenced from
+	// every operation.
+	var defaultParams []string
+	for _, in := range []string{"header", "query"} {
+		p, ok := prog.Config.AddDefaultParams[in]
+		if !ok {
+			continue
+		}
+
+		params, err := fieldParams(prog, prog.References[p.Reference], in)
+		if err != nil {
+			return err
+		}
+		if out.Parameters == nil {
+			out.Parameters = make(map[string]Parameter)
+		}
+		sort.Slice(params, func(i, j int) bool { return params[i].Name < params[j].Name })
+		for _, param := range params {
+			name := in + "." + param.Name
+			out.Parameters[name] = param
+			defaultParams = append(defaultParams, "#/parameters/"+name)
+		}
+	}
+
 	seenTags := map[string]struct{}{}
 
 	// Add endpoints.
@@ -201,10 +272,27 @@ func write(outFormat string, w io.Writer, prog *docparse.Program) error {
 
 		op := Operation{
 			Summary:     e.Tagline,
-			Description: e.Info,
+			Description: docparse.Markdown(prog, e.Info),
 			OperationID: makeID(e),
 			Tags:        e.Tags,
-			Responses:   map[int]Response{},
+			Responses:   map[string]Response{},
+			CodeSamples: snippet.Samples(prog, e, snippet.BaseURL(prog.Config)),
+		}
+
+		// Package-level auth.
+		switch e.Auth {
+		case "", prog.Config.Auth:
+			// Use the global auth.
+		case "none":
+			op.Security = &[]map[string]any{}
+		case "basic":
+			if out.SecurityDefinitions == nil {
+				out.SecurityDefinitions = map[string]any{}
+			}
+			out.SecurityDefinitions["basicAuth"] = map[string]any{"type": "basic"}
+			op.Security = &[]map[string]any{{"basicAuth": []string{}}}
+		default:
+			return fmt.Errorf("unknown auth value for %s %s: %q", e.Method, e.Path, e.Auth)
 		}
 
 		// Add their tags to the top level object to ensure ordering in
@@ -228,9 +316,13 @@ func write(outFormat string, w io.Writer, prog *docparse.Program) error {
 				op.Parameters = append(op.Parameters, Parameter{
 					Name:        name,
 					In:          "path",
-					Description: p.Description,
+					Description: docparse.Markdown(prog, p.Description),
 					Type:        p.Type,
+					Items:       p.Items,
 					Required:    true,
+					Example:     p.Example,
+
+					CollectionFormat: p.CollectionFormat,
 				})
 			}
 		}
@@ -239,57 +331,11 @@ func write(outFormat string, w io.Writer, prog *docparse.Program) error {
 		if e.Request.Query != nil {
 			// TODO: Don't access prog.References directly. This probably
 			// shouldn't be there anyway.
-			ref := prog.References[e.Request.Query.Reference]
-
-			for _, f := range ref.Fields {
-				// TODO: this should be done in docparse.
-				f.Name = zgo.TagName(f.KindField, "query")
-				if f.Name == "-" {
-					continue
-				}
-
-				schema := ref.Schema.Properties[f.Name]
-				if schema == nil {
-					return fmt.Errorf("schema is nil for query field %q in %q",
-						f.Name, e.Request.Query.Reference)
-				}
-				if schema.OmitDoc {
-					continue
-				}
-
-				queryType := schema.Type
-				if len(queryType) == 0 {
-					// if the parameter is a struct, and not mapped,
-					// we should fallback to a string to have a valid swagger file
-					// (we can not have a field without schema nor type )
-					queryType = "string"
-				}
-
-				items := schema.Items
-				if items != nil && len(items.Reference) != 0 {
-					// in swagger 2.0, arrays in the query can only
-					// contain basic type, so, if it holds a reference
-					// we change it to a string
-					items = &docparse.Schema{
-						Type: "string",
-					}
-				}
-
-				op.Parameters = append(op.Parameters, Parameter{
-					Name:        f.Name,
-					In:          "query",
-					Description: schema.Description,
-					Type:        queryType,
-					Items:       items,
-					Required:    len(schema.Required) > 0,
-					Readonly:    schema.Readonly,
-					Enum:        schema.Enum,
-					Default:     schema.Default,
-					Minimum:     schema.Minimum,
-					Maximum:     schema.Maximum,
-					Format:      schema.Format,
-				})
+			params, err := fieldParams(prog, prog.References[e.Request.Query.Reference], "query")
+			if err != nil {
+				return err
 			}
+			op.Parameters = append(op.Parameters, params...)
 		}
 
 		// Add form params,
@@ -321,12 +367,24 @@ func write(outFormat string, w io.Writer, prog *docparse.Program) error {
 					// (we can not have a field without schema nor type )
 					formType = "string"
 				}
+
+				desc := docparse.Markdown(prog, schema.Description)
+				items, collectionFormat := schema.Items, schema.CollectionFormat
+				if items != nil && items.Type == "file" {
+					// OpenAPI 2 doesn't allow arrays of files; the closest is
+					// a single file parameter, so mention it in the
+					// description.
+					formType = "file"
+					items, collectionFormat = nil, ""
+					desc = strings.TrimSpace(desc + "\n\nAccepts multiple files.")
+				}
+
 				op.Parameters = append(op.Parameters, Parameter{
 					Name:        f.Name,
 					In:          "formData",
-					Description: schema.Description,
+					Description: desc,
 					Type:        formType,
-					Items:       schema.Items,
+					Items:       items,
 					Required:    len(schema.Required) > 0,
 					Readonly:    schema.Readonly,
 					Enum:        schema.Enum,
@@ -334,9 +392,12 @@ func write(outFormat string, w io.Writer, prog *docparse.Program) error {
 					Minimum:     schema.Minimum,
 					Maximum:     schema.Maximum,
 					Format:      schema.Format,
+					Example:     schema.Example,
+
+					CollectionFormat: collectionFormat,
 				})
 			}
-			op.Consumes = append(op.Consumes, "application/x-www-form-urlencoded")
+			op.Consumes = append(op.Consumes, e.Request.FormContentType)
 		}
 
 		// Add any {..} parameters in the path to the parameter list if they
@@ -358,18 +419,37 @@ func write(outFormat string, w io.Writer, prog *docparse.Program) error {
 			}
 		}
 
-		if e.Request.Body != nil {
+		if len(e.Request.Bodies) > 0 {
+			var examples map[string]json.RawMessage
+			for ct, b := range e.Request.Bodies {
+				if b.Example != nil {
+					if examples == nil {
+						examples = make(map[string]json.RawMessage)
+					}
+					examples[ct] = b.Example
+				}
+			}
+
+			// OpenAPI 2 only allows one schema for all Content-Types.
+			body := schemaBody(e.Request.Bodies)
 			op.Parameters = append(op.Parameters, Parameter{
 				// TODO: name required, is there a better value to use?
-				Name:        e.Request.Body.Reference,
+				Name:        body.Reference,
 				In:          "body",
-				Description: e.Request.Body.Description,
+				Description: body.Description,
 				Required:    true,
 				Schema: &docparse.Schema{
-					Reference: "#/definitions/" + e.Request.Body.Reference,
+					Reference: "#/definitions/" + body.Reference,
 				},
+				Examples: examples,
 			})
-			op.Consumes = append(op.Consumes, e.Request.ContentType)
+			main := docparse.MainContentType(e.Request.Bodies)
+			op.Consumes = append(op.Consumes, main)
+			for _, ct := range docparse.ContentTypes(e.Request.Bodies) {
+				if ct != main {
+					op.Consumes = append(op.Consumes, ct)
+				}
+			}
 		}
 
 		// TODO: preserve order in which they were defined in the struct, but
@@ -377,28 +457,51 @@ func write(outFormat string, w io.Writer, prog *docparse.Program) error {
 		sort.Slice(op.Parameters, func(i, j int) bool {
 			return op.Parameters[i].Type+op.Parameters[i].Name > op.Parameters[j].Type+op.Parameters[j].Name
 		})
+		if !e.NoDefaultParams {
+			for _, ref := range defaultParams {
+				op.Parameters = append(op.Parameters, Parameter{Ref: ref})
+			}
+		}
 
 		for code, resp := range e.Responses {
-			r := Response{
-				Description: resp.Body.Description,
+			body := schemaBody(resp.Bodies)
+			r := Response{Description: resp.Summary()}
+			if resp.Description != "" {
+				r.Description = docparse.Markdown(prog, resp.Description)
 			}
 
 			// Link reference.
-			if resp.Body != nil && resp.Body.Reference != "" {
+			produces := resp.Bodies
+			if body != nil && body.Reference != "" {
 				r.Schema = &docparse.Schema{
-					Reference: "#/definitions/" + resp.Body.Reference,
+					Reference: "#/definitions/" + body.Reference,
 				}
 			} else if dr, ok := prog.Config.DefaultResponse[code]; ok {
-				r.Schema = &docparse.Schema{
-					Reference: "#/definitions/" + dr.Body.Reference,
+				if drBody := schemaBody(dr.Bodies); drBody != nil {
+					r.Schema = &docparse.Schema{
+						Reference: "#/definitions/" + drBody.Reference,
+					}
 				}
-				if dr.ContentType != "" {
-					resp.ContentType = dr.ContentType
+				produces = dr.Bodies
+			}
+
+			for ct, b := range resp.Bodies {
+				if b.Example != nil {
+					if r.Examples == nil {
+						r.Examples = make(map[string]json.RawMessage)
+					}
+					r.Examples[ct] = b.Example
 				}
 			}
 
+			// OpenAPI 2 doesn't support ranges, so add them as an extension.
+			if strings.HasSuffix(code, "XX") {
+				code = "x-" + code
+			}
 			op.Responses[code] = r
-			op.Produces = appendIfNotExists(op.Produces, resp.ContentType)
+			for ct := range produces {
+				op.Produces = appendIfNotExists(op.Produces, ct)
+			}
 		}
 
 		sort.Strings(op.Produces)
@@ -459,11 +562,85 @@ func write(outFormat string, w io.Writer, prog *docparse.Program) error {
 	return err
 }
 
+// Get the parameters for all fields in a query or header reference.
+func fieldParams(prog *docparse.Program, ref docparse.Reference, in string) ([]Parameter, error) {
+	var params []Parameter
+	for _, f := range ref.Fields {
+		// TODO: this should be done in docparse.
+		f.Name = zgo.TagName(f.KindField, in)
+		if f.Name == "-" {
+			continue
+		}
+
+		schema := ref.Schema.Properties[f.Name]
+		if schema == nil {
+			return nil, fmt.Errorf("schema is nil for %s field %q in %q",
+				in, f.Name, ref.Lookup)
+		}
+		if schema.OmitDoc {
+			continue
+		}
+
+		paramType := schema.Type
+		if len(paramType) == 0 {
+			// if the parameter is a struct, and not mapped,
+			// we should fallback to a string to have a valid swagger file
+			// (we can not have a field without schema nor type )
+			paramType = "string"
+		}
+
+		items := schema.Items
+		if items != nil && len(items.Reference) != 0 {
+			// in swagger 2.0, arrays in the query can only
+			// contain basic type, so, if it holds a reference
+			// we change it to a string
+			items = &docparse.Schema{
+				Type: "string",
+			}
+		}
+
+		params = append(params, Parameter{
+			Name:        f.Name,
+			In:          in,
+			Description: docparse.Markdown(prog, schema.Description),
+			Type:        paramType,
+			Items:       items,
+			Required:    len(schema.Required) > 0,
+			Readonly:    schema.Readonly,
+			Enum:        schema.Enum,
+			Default:     schema.Default,
+			Minimum:     schema.Minimum,
+			Maximum:     schema.Maximum,
+			Format:      schema.Format,
+			Example:     schema.Example,
+
+			CollectionFormat: schema.CollectionFormat,
+		})
+	}
+	return params, nil
+}
+
 func makeID(e *docparse.Endpoint) string {
 	return strings.Replace(fmt.Sprintf("%v_%v", e.Method,
 		strings.Replace(e.Path, "/", "_", -1)), "__", "_", 1)
 }
 
+// Get the body to use as the schema; this is the body for the main
+// Content-Type, unless it doesn't have a reference (e.g. {data}) and one of the
+// other Content-Types does.
+func schemaBody(bodies map[string]*docparse.Ref) *docparse.Ref {
+	main := bodies[docparse.MainContentType(bodies)]
+	if main == nil || main.Reference != "" {
+		return main
+	}
+	for _, ct := range docparse.ContentTypes(bodies) {
+		if bodies[ct].Reference != "" {
+			return bodies[ct]
+		}
+	}
+	return main
+}
+
 func appendIfNotExists(xs []string, y string) []string {
 	for _, x := range xs {
 		if x == y {
@@ -473,6 +650,22 @@ func appendIfNotExists(xs []string, y string) []string {
 	return append(xs, y)
 }
 
+// Convert the descriptions in the schema and all nested schemas to CommonMark.
+func markdownDescriptions(prog *docparse.Program, schema *docparse.Schema) {
+	if schema == nil {
+		return
+	}
+	schema.Description = docparse.Markdown(prog, schema.Description)
+	for _, p := range schema.Properties {
+		markdownDescriptions(prog, p)
+	}
+	for _, a := range schema.AllOf {
+		markdownDescriptions(prog, a)
+	}
+	markdownDescriptions(prog, schema.Items)
+	markdownDescriptions(prog, schema.AdditionalProperties)
+}
+
 func prefixPropertyReferences(properties map[string]*docparse.Schema) {
 	var rm []string
 	for k, s := range properties {
@@ -497,9 +690,63 @@ func prefixPropertyReferences(properties map[string]*docparse.Schema) {
 		if s.Properties != nil {
 			prefixPropertyReferences(s.Properties)
 		}
+		prefixAllOfReferences(s.AllOf)
 	}
 
 	for _, r := range rm {
 		delete(properties, r)
 	}
 }
+
+// allOf can only be set from schema files.
+func prefixAllOfReferences(allOf []*docparse.Schema) {
+	for _, s := range allOf {
+		if s.Reference != "" && !strings.HasPrefix(s.Reference, "#/definitions/") {
+			s.Reference = "#/definitions/" + s.Reference
+		}
+		prefixPropertyReferences(s.Properties)
+	}
+}
+
+// Copy the schema and all nested schemas.
+func copySchema(s *docparse.Schema) *docparse.Schema {
+	if s == nil {
+		return nil
+	}
+	c := *s
+	c.Items = copySchema(s.Items)
+	c.AdditionalProperties = copySchema(s.AdditionalProperties)
+	if s.AllOf != nil {
+		c.AllOf = make([]*docparse.Schema, len(s.AllOf))
+		for i, a := range s.AllOf {
+			c.AllOf[i] = copySchema(a)
+		}
+	}
+	if s.Properties != nil {
+		c.Properties = make(map[string]*docparse.Schema, len(s.Properties))
+		for k, p := range s.Properties {
+			c.Properties[k] = copySchema(p)
+		}
+	}
+	return &c
+}
+
+// OpenAPI 2 ignores everything next to a $ref, so wrap nullable references in
+// an allOf to keep the x-nullable.
+func nullableReferences(s *docparse.Schema) {
+	if s == nil {
+		return
+	}
+	if s.Reference != "" && s.Nullable {
+		s.AllOf = []*docparse.Schema{{Reference: s.Reference}}
+		s.Reference = ""
+	}
+	for _, p := range s.Properties {
+		nullableReferences(p)
+	}
+	for _, a := range s.AllOf {
+		nullableReferences(a)
+	}
+	nullableReferences(s.Items)
+	nullableReferences(s.AdditionalProperties)
+}
@@ -0,0 +1,388 @@
+// Package postman outputs a Postman Collection v2.1.
+//
+// Requests are grouped in a folder per tag, and use the {{baseUrl}} collection
+// variable, which is set to the first server. Path parameters are added as
+// :param variables.
+package postman
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"zgo.at/kommentaar/docparse"
+	"zgo.at/kommentaar/snippet"
+)
+
+// Schema is the URL for the Postman Collection v2.1 schema.
+const Schema = "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+
+type (
+	// Collection is the top-level Postman collection.
+	Collection struct {
+		Info     Info       `json:"info"`
+		Item     []Item     `json:"item"`
+		Auth     *Auth      `json:"auth,omitempty"`
+		Variable []Variable `json:"variable,omitempty"`
+	}
+
+	// Info about the collection.
+	Info struct {
+		Name        string `json:"name"`
+		Description string `json:"description,omitempty"`
+		Version     string `json:"version,omitempty"`
+		Schema      string `json:"schema"`
+	}
+
+	// Item is either a folder (with Item) or a request (with Request).
+	Item struct {
+		Name        string     `json:"name"`
+		Description string     `json:"description,omitempty"`
+		Item        []Item     `json:"item,omitempty"`
+		Request     *Request   `json:"request,omitempty"`
+		Response    []Response `json:"response,omitempty"`
+	}
+
+	// Request to send.
+	Request struct {
+		Method      string     `json:"method"`
+		Header      []Variable `json:"header"`
+		URL         URL        `json:"url"`
+		Body        *Body      `json:"body,omitempty"`
+		Auth        *Auth      `json:"auth,omitempty"`
+		Description string     `json:"description,omitempty"`
+	}
+
+	// URL of a request.
+	URL struct {
+		Raw      string     `json:"raw"`
+		Host     []string   `json:"host"`
+		Path     []string   `json:"path"`
+		Query    []Variable `json:"query,omitempty"`
+		Variable []Variable `json:"variable,omitempty"`
+	}
+
+	// Variable is a key/value pair, used for variables, headers, query
+	// parameters, and form fields.
+	Variable struct {
+		Key         string `json:"key"`
+		Value       string `json:"value"`
+		Type        string `json:"type,omitempty"`
+		Description string `json:"description,omitempty"`
+		Disabled    bool   `json:"disabled,omitempty"`
+	}
+
+	// Body of a request.
+	Body struct {
+		Mode       string         `json:"mode"`
+		Raw        string         `json:"raw,omitempty"`
+		URLEncoded []Variable     `json:"urlencoded,omitempty"`
+		FormData   []Variable     `json:"formdata,omitempty"`
+		Options    map[string]any `json:"options,omitempty"`
+	}
+
+	// Auth for a request or the collection.
+	Auth struct {
+		Type  string     `json:"type"`
+		Basic []Variable `json:"basic,omitempty"`
+	}
+
+	// Response is a saved example response.
+	Response struct {
+		Name            string     `json:"name"`
+		OriginalRequest *Request   `json:"originalRequest"`
+		Status          string     `json:"status,omitempty"`
+		Code            int        `json:"code,omitempty"`
+		Language        string     `json:"_postman_previewlanguage,omitempty"`
+		Header          []Variable `json:"header"`
+		Body            string     `json:"body,omitempty"`
+	}
+)
+
+// WritePostman writes a Postman Collection v2.1 as indented JSON.
+func WritePostman(w io.Writer, prog *docparse.Program) error {
+	c := Collection{
+		Info: Info{
+			Name:        prog.Config.Title,
+			Description: strings.TrimSpace(string(prog.Config.Description)),
+			Version:     prog.Config.Version,
+			Schema:      Schema,
+		},
+		Variable: []Variable{{Key: "baseUrl", Value: snippet.BaseURL(prog.Config)}},
+		Item:     []Item{},
+	}
+
+	switch prog.Config.Auth {
+	case "":
+	case "basic":
+		c.Auth = basicAuth()
+		c.Variable = append(c.Variable, Variable{Key: "username"}, Variable{Key: "password"})
+	default:
+		return fmt.Errorf("unknown auth value: %q", prog.Config.Auth)
+	}
+
+	folders := make(map[string]*Item)
+	var tags []string
+	for _, e := range prog.Endpoints {
+		items, err := requests(prog, e)
+		if err != nil {
+			return err
+		}
+
+		if len(e.Tags) == 0 {
+			c.Item = append(c.Item, items...)
+			continue
+		}
+		f, ok := folders[e.Tags[0]]
+		if !ok {
+			f = &Item{Name: e.Tags[0]}
+			folders[e.Tags[0]] = f
+			tags = append(tags, e.Tags[0])
+		}
+		f.Item = append(f.Item, items...)
+	}
+
+	sort.Strings(tags)
+	folderItems := make([]Item, 0, len(tags))
+	for _, t := range tags {
+		folderItems = append(folderItems, *folders[t])
+	}
+	c.Item = append(folderItems, c.Item...)
+
+	j, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("postman: %v", err)
+	}
+	_, err = w.Write(append(j, '\n'))
+	return err
+}
+
+func basicAuth() *Auth {
+	return &Auth{Type: "basic", Basic: []Variable{
+		{Key: "username", Value: "{{username}}", Type: "string"},
+		{Key: "password", Value: "{{password}}", Type: "string"},
+	}}
+}
+
+// Create the requests for an endpoint; this is one request for every
+// Content-Type the request body can be sent as.
+func requests(prog *docparse.Program, e *docparse.Endpoint) ([]Item, error) {
+	main := docparse.MainContentType(e.Request.Bodies)
+	if len(e.Request.Bodies) < 2 || e.Request.Form != nil {
+		item, err := request(prog, e, main, "")
+		return []Item{item}, err
+	}
+
+	cts := []string{main}
+	for _, ct := range docparse.ContentTypes(e.Request.Bodies) {
+		if ct != main {
+			cts = append(cts, ct)
+		}
+	}
+
+	items := make([]Item, 0, len(cts))
+	for _, ct := range cts {
+		item, err := request(prog, e, ct, " ("+ct+")")
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// Create the request for an endpoint, with the request body for the
+// Content-Type ct.
+func request(prog *docparse.Program, e *docparse.Endpoint, ct, suffix string) (Item, error) {
+	path := prog.Config.Prefix + e.Path
+	name := e.Tagline
+	if name == "" {
+		name = e.Method + " " + path
+	}
+	name += suffix
+
+	req := Request{
+		Method:      e.Method,
+		Header:      []Variable{},
+		Description: docparse.Markdown(prog, e.Info),
+		URL:         url(prog, e, path),
+	}
+
+	switch e.Auth {
+	case "", prog.Config.Auth:
+	case "none":
+		req.Auth = &Auth{Type: "noauth"}
+	case "basic":
+		req.Auth = basicAuth()
+	default:
+		return Item{}, fmt.Errorf("unknown auth value for %s %s: %q", e.Method, e.Path, e.Auth)
+	}
+
+	switch {
+	case e.Request.Form != nil:
+		// Postman sets the Content-Type (with the multipart boundary).
+		if e.Request.FormContentType == "multipart/form-data" {
+			req.Body = &Body{Mode: "formdata", FormData: params(prog, e.Request.Form, true)}
+		} else {
+			req.Body = &Body{Mode: "urlencoded", URLEncoded: params(prog, e.Request.Form, true)}
+		}
+	case e.Request.Bodies[ct] != nil:
+		body := e.Request.Bodies[ct]
+		req.Header = append(req.Header, Variable{Key: "Content-Type", Value: ct})
+		req.Body = &Body{Mode: "raw", Raw: example(prog, body)}
+		if strings.Contains(ct, "json") {
+			req.Body.Options = map[string]any{"raw": map[string]string{"language": "json"}}
+		}
+	}
+
+	item := Item{Name: name, Request: &req}
+
+	codes := make([]string, 0, len(e.Responses))
+	for c := range e.Responses {
+		codes = append(codes, c)
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		resp := e.Responses[code]
+		cts := docparse.ContentTypes(resp.Bodies)
+
+		// Add a response for every Content-Type.
+		for _, ct := range cts {
+			r := Response{
+				Name:            strings.TrimSpace(code + " " + docparse.StatusText(code)),
+				OriginalRequest: &req,
+				Status:          docparse.StatusText(code),
+				Header:          []Variable{},
+			}
+			if len(cts) > 1 {
+				r.Name += " (" + ct + ")"
+			}
+			if n, err := strconv.Atoi(code); err == nil {
+				r.Code = n
+			}
+
+			if body := resp.Bodies[ct]; body.Reference != "" || body.Example != nil {
+				r.Header = append(r.Header, Variable{Key: "Content-Type", Value: ct})
+				r.Body = example(prog, body)
+				if strings.Contains(ct, "json") {
+					r.Language = "json"
+				}
+			}
+			item.Response = append(item.Response, r)
+		}
+	}
+
+	return item, nil
+}
+
+// Get the URL with {{baseUrl}}, :param path variables, and the query
+// parameters.
+func url(prog *docparse.Program, e *docparse.Endpoint, path string) URL {
+	u := URL{Host: []string{"{{baseUrl}}"}}
+
+	pathVars := make(map[string]Variable)
+	if e.Request.Path != nil {
+		for _, v := range params(prog, e.Request.Path, false) {
+			pathVars[v.Key] = v
+		}
+	}
+	for _, p := range docparse.PathParams(path) {
+		path = strings.ReplaceAll(path, "{"+p+"}", ":"+p)
+		v, ok := pathVars[p]
+		if !ok {
+			v = Variable{Key: p}
+		}
+		u.Variable = append(u.Variable, v)
+	}
+	u.Path = strings.Split(strings.Trim(path, "/"), "/")
+	u.Raw = "{{baseUrl}}" + path
+
+	if e.Request.Query != nil {
+		u.Query = params(prog, e.Request.Query, false)
+		var q []string
+		for _, v := range u.Query {
+			if !v.Disabled {
+				q = append(q, v.Key+"="+v.Value)
+			}
+		}
+		if len(q) > 0 {
+			u.Raw += "?" + strings.Join(q, "&")
+		}
+	}
+	return u
+}
+
+// Get the parameters for a path, query, or form reference, prefilled with the
+// default or example value. Optional parameters without a value are disabled.
+func params(prog *docparse.Program, r *docparse.Ref, form bool) []Variable {
+	ref, ok := prog.References[r.Reference]
+	if !ok || ref.Schema == nil {
+		return nil
+	}
+
+	vars := make([]Variable, 0, len(ref.Schema.PropertyOrder))
+	for _, name := range ref.Schema.PropertyOrder {
+		p := ref.Schema.Properties[name]
+		if p.OmitDoc {
+			continue
+		}
+
+		v := Variable{Key: name, Description: docparse.Markdown(prog, p.Description)}
+		switch {
+		case p.Type == "file" || (p.Items != nil && p.Items.Type == "file"):
+			v.Type = "file"
+		case p.Default != nil:
+			v.Value = value(p.Default)
+		case p.Example != nil:
+			v.Value = value(p.Example)
+		case ref.Context == "path":
+			v.Value = value(docparse.ExampleFor(prog, p))
+		}
+		if form && v.Type == "" {
+			v.Type = "text"
+		}
+		if v.Value == "" && v.Type != "file" && len(p.Required) == 0 && ref.Context != "path" {
+			v.Disabled = true
+		}
+		vars = append(vars, v)
+	}
+	return vars
+}
+
+// Get an example body as indented JSON.
+func example(prog *docparse.Program, body *docparse.Ref) string {
+	ex := body.Example
+	if ex == nil {
+		ref, ok := prog.References[body.Reference]
+		if !ok {
+			return ""
+		}
+		ex, _ = json.Marshal(docparse.ExampleFor(prog, ref.Schema))
+	}
+
+	b := new(bytes.Buffer)
+	if err := json.Indent(b, ex, "", "    "); err != nil {
+		return string(ex)
+	}
+	return b.String()
+}
+
+func value(v any) string {
+	switch vv := v.(type) {
+	case string:
+		return vv
+	case []any:
+		s := make([]string, 0, len(vv))
+		for _, item := range vv {
+			s = append(s, value(item))
+		}
+		return strings.Join(s, ",")
+	default:
+		j, _ := json.Marshal(v)
+		return string(j)
+	}
+}
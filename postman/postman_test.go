@@ -0,0 +1,104 @@
+package postman
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"zgo.at/kommentaar/docparse"
+)
+
+func TestPostman(t *testing.T) {
+	prog := docparse.NewProgram(false)
+	prog.Config.Packages = []string{"../example/..."}
+	prog.Config.Output = WritePostman
+	prog.Config.Title = "Example"
+	prog.Config.Version = "1.0"
+	prog.Config.Host = "api.example.com"
+
+	w := new(bytes.Buffer)
+	err := docparse.FindComments(w, prog)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var c Collection
+	if err := json.Unmarshal(w.Bytes(), &c); err != nil {
+		t.Fatal(err)
+	}
+
+	if c.Info.Name != "Example" || c.Info.Version != "1.0" || c.Info.Schema != Schema {
+		t.Errorf("wrong info: %#v", c.Info)
+	}
+	if len(c.Variable) != 1 || c.Variable[0].Value != "https://api.example.com" {
+		t.Errorf("wrong variables: %#v", c.Variable)
+	}
+
+	var (
+		found  bool
+		folder Item
+	)
+	for _, item := range c.Item {
+		if item.Name == "foobar" {
+			folder, found = item, true
+		}
+	}
+	if !found {
+		t.Fatalf("no foobar folder in %#v", c.Item)
+	}
+
+	for _, item := range folder.Item {
+		if item.Request.URL.Raw != "{{baseUrl}}/foo/:id" {
+			continue
+		}
+		if len(item.Request.URL.Variable) != 1 || item.Request.URL.Variable[0].Key != "id" {
+			t.Errorf("wrong path variables: %#v", item.Request.URL.Variable)
+		}
+		if item.Request.Body == nil || item.Request.Body.Raw == "" {
+			t.Errorf("no example body: %#v", item.Request.Body)
+		}
+		if len(item.Response) == 0 {
+			t.Error("no example responses")
+		}
+		return
+	}
+	t.Errorf("no request for /foo/{id} in %#v", folder.Item)
+}
+
+func TestContentTypes(t *testing.T) {
+	prog := docparse.NewProgram(false)
+	prog.Config.Packages = []string{"../testdata/content-types"}
+	prog.Config.Output = WritePostman
+	prog.Config.Title = "Example"
+	prog.Config.Version = "1.0"
+	prog.Config.StructTag = "json"
+
+	w := new(bytes.Buffer)
+	err := docparse.FindComments(w, prog)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var c Collection
+	if err := json.Unmarshal(w.Bytes(), &c); err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, item := range c.Item {
+		names = append(names, item.Name)
+		var resp []string
+		for _, r := range item.Response {
+			resp = append(resp, r.Name)
+		}
+		want := "[200 OK (application/json) 200 OK (text/csv)]"
+		if fmt.Sprint(resp) != want {
+			t.Errorf("%s: wrong responses\nout:  %s\nwant: %s", item.Name, resp, want)
+		}
+	}
+	want := "[Create report. (application/json) Create report. (application/xml)]"
+	if fmt.Sprint(names) != want {
+		t.Errorf("wrong requests\nout:  %s\nwant: %s", names, want)
+	}
+}
@@ -0,0 +1,305 @@
+// Package snippet generates command lines to send a request to an endpoint.
+//
+// The request is built from the endpoint documentation with example values for
+// the path parameters, required query parameters, form fields, and body:
+//
+//	r := snippet.New(prog, e, snippet.BaseURL(prog.Config))
+//	fmt.Println(snippet.Curl(r))
+package snippet
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"zgo.at/kommentaar/docparse"
+)
+
+// Request to send.
+type Request struct {
+	Method  string
+	URL     string   // Full URL, including the query string.
+	Auth    string   // Authentication: "basic" or blank.
+	Headers []Field  // Request headers.
+	Form    []Field  // Form fields; Body is blank if this is set.
+	Body    string   // Request body.
+	Files   []string // Form fields with file uploads.
+
+	// Send the form as multipart/form-data instead of
+	// application/x-www-form-urlencoded.
+	Multipart bool
+}
+
+// Field is a name/value pair for a header or form field.
+type Field struct {
+	Name, Value string
+}
+
+// Sample is a snippet for a generator.
+type Sample struct {
+	Lang   string `json:"lang"`
+	Label  string `json:"label"`
+	Source string `json:"source"`
+}
+
+// Generator for snippets.
+type Generator struct {
+	Name string // Name in the configuration, e.g. "curl".
+	Lang string // Language for syntax highlighting, e.g. "Shell".
+	Func func(Request) string
+}
+
+// Generators are all supported snippet generators.
+var Generators = []Generator{
+	{Name: "curl", Lang: "Shell", Func: Curl},
+	{Name: "httpie", Lang: "Shell", Func: HTTPie},
+}
+
+// Get a generator by name.
+func Get(name string) (Generator, bool) {
+	for _, g := range Generators {
+		if g.Name == name {
+			return g, true
+		}
+	}
+	return Generator{}, false
+}
+
+// Names of all generators.
+func Names() []string {
+	names := make([]string, 0, len(Generators))
+	for _, g := range Generators {
+		names = append(names, g.Name)
+	}
+	return names
+}
+
+// BaseURL gets the URL to add the endpoint path to; this is the first server,
+// or http://localhost with the Basepath if there are no servers.
+func BaseURL(c docparse.Config) string {
+	if s := c.AllServers(); len(s) > 0 {
+		return strings.TrimRight(s[0].Expand(), "/")
+	}
+	return "http://localhost" + c.Basepath
+}
+
+// Samples gets the snippets for all generators in Config.CodeSamples.
+func Samples(prog *docparse.Program, e *docparse.Endpoint, base string) []Sample {
+	if len(prog.Config.CodeSamples) == 0 {
+		return nil
+	}
+
+	r := New(prog, e, base)
+	samples := make([]Sample, 0, len(prog.Config.CodeSamples))
+	for _, name := range prog.Config.CodeSamples {
+		g, ok := Get(name)
+		if !ok {
+			continue
+		}
+		samples = append(samples, Sample{Lang: g.Lang, Label: g.Name, Source: g.Func(r)})
+	}
+	return samples
+}
+
+// New creates a new request for the endpoint.
+//
+// The e.Path is added to base as-is, so it should include Config.Prefix if
+// it's set. The body is sent with docparse.MainContentType if the endpoint
+// accepts more than one.
+func New(prog *docparse.Program, e *docparse.Endpoint, base string) Request {
+	r := Request{Method: e.Method}
+
+	auth := e.Auth
+	if auth == "" {
+		auth = prog.Config.Auth
+	}
+	if auth == "basic" {
+		r.Auth = auth
+	}
+
+	// Path parameters.
+	path := e.Path
+	params := map[string]string{}
+	for _, f := range fields(prog, e.Request.Path, false) {
+		params[f.Name] = f.Value
+	}
+	for _, p := range docparse.PathParams(e.Path) {
+		v, ok := params[p]
+		if !ok {
+			v = "1"
+		}
+		path = strings.ReplaceAll(path, "{"+p+"}", url.PathEscape(v))
+	}
+	r.URL = base + path
+
+	// Query parameters.
+	if q := fields(prog, e.Request.Query, true); len(q) > 0 {
+		vals := make([]string, 0, len(q))
+		for _, f := range q {
+			vals = append(vals, url.QueryEscape(f.Name)+"="+url.QueryEscape(f.Value))
+		}
+		r.URL += "?" + strings.Join(vals, "&")
+	}
+
+	// Form or body.
+	switch {
+	case e.Request.Form != nil:
+		r.Multipart = e.Request.FormContentType == "multipart/form-data"
+		ref, ok := prog.References[e.Request.Form.Reference]
+		if !ok || ref.Schema == nil {
+			break
+		}
+		for _, name := range ref.Schema.PropertyOrder {
+			p := ref.Schema.Properties[name]
+			switch {
+			case p.OmitDoc:
+			case p.Type == "file" || (p.Items != nil && p.Items.Type == "file"):
+				r.Files = append(r.Files, name)
+			default:
+				r.Form = append(r.Form, Field{Name: name, Value: value(docparse.ExampleFor(prog, p))})
+			}
+		}
+	case len(e.Request.Bodies) > 0:
+		ct := docparse.MainContentType(e.Request.Bodies)
+		body := e.Request.Bodies[ct].Example
+		if body == nil {
+			if ref, ok := prog.References[e.Request.Bodies[ct].Reference]; ok {
+				body, _ = json.Marshal(docparse.ExampleFor(prog, ref.Schema))
+			}
+		}
+		if body != nil {
+			r.Body = compact(body)
+			r.Headers = append(r.Headers, Field{Name: "Content-Type", Value: ct})
+		}
+	}
+
+	return r
+}
+
+// Curl creates a curl command line.
+func Curl(r Request) string {
+	args := []string{"curl"}
+	switch r.Method {
+	case "GET":
+	case "HEAD":
+		args = append(args, "-I")
+	default:
+		args = append(args, "-X "+r.Method)
+	}
+	lines := []string{strings.Join(append(args, quote(r.URL)), " ")}
+
+	if r.Auth == "basic" {
+		lines = append(lines, "-u "+quote("user:password"))
+	}
+	for _, h := range r.Headers {
+		lines = append(lines, "-H "+quote(h.Name+": "+h.Value))
+	}
+
+	flag := "--data-urlencode "
+	if r.Multipart {
+		flag = "-F "
+	}
+	for _, f := range r.Form {
+		lines = append(lines, flag+quote(f.Name+"="+f.Value))
+	}
+	for _, f := range r.Files {
+		lines = append(lines, "-F "+quote(f+"=@"+f))
+	}
+	if r.Body != "" {
+		lines = append(lines, "--data-raw "+quote(r.Body))
+	}
+
+	return strings.Join(lines, " \\\n    ")
+}
+
+// HTTPie creates a HTTPie command line.
+func HTTPie(r Request) string {
+	args := []string{"http"}
+	switch {
+	case r.Multipart:
+		args = append(args, "--multipart")
+	case len(r.Form) > 0 || len(r.Files) > 0:
+		args = append(args, "--form")
+	}
+	lines := []string{strings.Join(append(args, r.Method, quote(r.URL)), " ")}
+
+	if r.Auth == "basic" {
+		lines = append(lines, "-a "+quote("user:password"))
+	}
+	for _, h := range r.Headers {
+		lines = append(lines, quote(h.Name+":"+h.Value))
+	}
+	for _, f := range r.Form {
+		lines = append(lines, quote(f.Name+"="+f.Value))
+	}
+	for _, f := range r.Files {
+		lines = append(lines, quote(f+"@"+f))
+	}
+	if r.Body != "" {
+		lines = append(lines, "--raw "+quote(r.Body))
+	}
+
+	return strings.Join(lines, " \\\n    ")
+}
+
+// Get example values for all parameters in the reference; only required
+// parameters are used if onlyRequired is set.
+func fields(prog *docparse.Program, r *docparse.Ref, onlyRequired bool) []Field {
+	if r == nil {
+		return nil
+	}
+	ref, ok := prog.References[r.Reference]
+	if !ok || ref.Schema == nil {
+		return nil
+	}
+
+	order := ref.Schema.PropertyOrder
+	if len(order) == 0 {
+		for name := range ref.Schema.Properties {
+			order = append(order, name)
+		}
+		sort.Strings(order)
+	}
+
+	var f []Field
+	for _, name := range order {
+		p := ref.Schema.Properties[name]
+		if p == nil || p.OmitDoc || (onlyRequired && len(p.Required) == 0) {
+			continue
+		}
+		f = append(f, Field{Name: name, Value: value(docparse.ExampleFor(prog, p))})
+	}
+	return f
+}
+
+// Get a parameter value; arrays are joined with commas.
+func value(v any) string {
+	switch vv := v.(type) {
+	case nil:
+		return ""
+	case []any:
+		s := make([]string, 0, len(vv))
+		for _, item := range vv {
+			s = append(s, value(item))
+		}
+		return strings.Join(s, ",")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func compact(b []byte) string {
+	buf := new(bytes.Buffer)
+	if err := json.Compact(buf, b); err != nil {
+		return string(b)
+	}
+	return buf.String()
+}
+
+// Quote s for the shell.
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
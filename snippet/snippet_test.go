@@ -0,0 +1,65 @@
+package snippet
+
+import (
+	"testing"
+
+	"zgo.at/kommentaar/docparse"
+)
+
+func TestGenerators(t *testing.T) {
+	tests := []struct {
+		name       string
+		in         Request
+		curl, http string
+	}{
+		{"get", Request{Method: "GET", URL: "http://localhost/x"},
+			`curl 'http://localhost/x'`,
+			`http GET 'http://localhost/x'`},
+		{"head", Request{Method: "HEAD", URL: "http://localhost/x", Auth: "basic"},
+			"curl -I 'http://localhost/x' \\\n    -u 'user:password'",
+			"http HEAD 'http://localhost/x' \\\n    -a 'user:password'"},
+		{"body", Request{Method: "POST", URL: "http://localhost/x",
+			Headers: []Field{{"Content-Type", "application/json"}}, Body: `{"name":"it's"}`},
+			"curl -X POST 'http://localhost/x' \\\n    -H 'Content-Type: application/json' \\\n    --data-raw '{\"name\":\"it'\\''s\"}'",
+			"http POST 'http://localhost/x' \\\n    'Content-Type:application/json' \\\n    --raw '{\"name\":\"it'\\''s\"}'"},
+		{"form", Request{Method: "PUT", URL: "http://localhost/x", Form: []Field{{"a", "b"}}},
+			"curl -X PUT 'http://localhost/x' \\\n    --data-urlencode 'a=b'",
+			"http --form PUT 'http://localhost/x' \\\n    'a=b'"},
+		{"multipart", Request{Method: "PUT", URL: "http://localhost/x", Form: []Field{{"a", "b"}},
+			Files: []string{"f"}, Multipart: true},
+			"curl -X PUT 'http://localhost/x' \\\n    -F 'a=b' \\\n    -F 'f=@f'",
+			"http --multipart PUT 'http://localhost/x' \\\n    'a=b' \\\n    'f@f'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if out := Curl(tt.in); out != tt.curl {
+				t.Errorf("curl\nout:  %s\nwant: %s", out, tt.curl)
+			}
+			if out := HTTPie(tt.in); out != tt.http {
+				t.Errorf("httpie\nout:  %s\nwant: %s", out, tt.http)
+			}
+		})
+	}
+}
+
+func TestBaseURL(t *testing.T) {
+	tests := []struct {
+		in   docparse.Config
+		want string
+	}{
+		{docparse.Config{}, "http://localhost"},
+		{docparse.Config{Basepath: "/v1"}, "http://localhost/v1"},
+		{docparse.Config{Host: "api.example.com", Basepath: "/v1"}, "https://api.example.com/v1"},
+		{docparse.Config{Servers: []docparse.Server{{URL: "http://{env}.example.com/",
+			Variables: map[string]docparse.ServerVariable{"env": {Default: "prod"}}}}}, "http://prod.example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if out := BaseURL(tt.in); out != tt.want {
+				t.Errorf("\nout:  %s\nwant: %s", out, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package console
+
+type bike struct {
+	Name  string `json:"name"`
+	Frame int    `json:"frame"`
+}
+
+type params struct {
+	DryRun bool   `query:"dry_run"`
+	Fields string `query:"fields"`
+}
+
+// PUT /bikes/{id}
+// Update a bike.
+//
+// Query: params
+// Request body: bike
+// Response 200: bike
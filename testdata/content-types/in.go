@@ -0,0 +1,16 @@
+package ct
+
+type report struct {
+	Total int `json:"total"`
+	Page  int `json:"page"`  // {range: 1-0}
+	Limit int `json:"limit"` // {range: 0-100}
+	Year  int `json:"year"`  // {range: 2000-2099}
+}
+
+// POST /report
+// Create report.
+//
+// Request body (application/json): report
+// Request body (application/xml): report
+// Response 200 (text/csv): {data}
+// Response 200 (application/json): report
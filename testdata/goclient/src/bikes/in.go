@@ -0,0 +1,76 @@
+package bikes
+
+import "net/http"
+
+// Bike is a bike.
+type Bike struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"` // {required}
+}
+
+// BikeList is a list of bikes.
+type BikeList struct {
+	Bikes []Bike `json:"bikes"`
+}
+
+// Error response.
+type Error struct {
+	Message string `json:"message"`
+}
+
+type bikePath struct {
+	ID int64 `path:"id"`
+}
+
+type listQuery struct {
+	Size int      `query:"size"` // {required}
+	Tags []string `query:"tags"`
+}
+
+type renameForm struct {
+	Name string `form:"name"` // {required}
+}
+
+type uploadForm struct {
+	Caption string `form:"caption"`
+	Photo   []byte `form:"photo"` // {required} {file}
+}
+
+// GET /bikes
+// List bikes.
+//
+// Query: listQuery
+// Response 200: BikeList
+// Response 4XX: Error
+func ListBikes(w http.ResponseWriter, r *http.Request) {}
+
+// POST /bikes/{id}
+// Update a bike.
+//
+// Path: bikePath
+// Request body: Bike
+// Response 200: Bike
+// Response default: Error
+func updateBike(w http.ResponseWriter, r *http.Request) {}
+
+// GET /bikes/{id}
+// Get a bike.
+//
+// Path: bikePath
+// Response 200 (application/json): Bike
+// Response 200 (application/xml): Bike
+// Response 200 (text/csv): {data}
+// Response 404 (application/json): Error
+// Response 404 (application/xml): BikeList
+
+// POST /bikes/{id}/rename
+//
+// Path: bikePath
+// Form: renameForm
+// Response 204: {empty}
+
+// PUT /bikes/{id}/photo
+//
+// Path: bikePath
+// Form: uploadForm
+// Response 204: {empty}
@@ -0,0 +1,6 @@
+package auth
+
+// GET /bikes
+// List bikes.
+//
+// Response 200: {empty}
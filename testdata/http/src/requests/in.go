@@ -0,0 +1,50 @@
+package requests
+
+type bike struct {
+	Name string `json:"name"` // {required}
+
+	// Frame colour {default: black}.
+	Color string `json:"color"`
+}
+
+type pathParams struct {
+	ID int `path:"id"` // {example: 42}
+}
+
+type queryParams struct {
+	Size   int    `query:"size"` // {required} {default: 20}
+	Filter string `query:"filter"`
+}
+
+type formParams struct {
+	Name string `form:"name"` // {required} {example: my bike}
+}
+
+type uploadParams struct {
+	Name  string `form:"name"`  // {required}
+	Photo []byte `form:"photo"` // {required} {file}
+}
+
+// POST /bikes/{id}
+// Update a bike.
+//
+// Request body: bike
+// Path: pathParams
+// Query: queryParams
+// Response 204: {empty}
+
+// GET /bikes
+//
+// Response 200: {empty}
+
+// POST /bikes/{id}/rename
+// Rename a bike.
+//
+// Form: formParams
+// Response 204: {empty}
+
+// PUT /bikes/{id}/photo
+// Upload a photo.
+//
+// Form: uploadParams
+// Response 204: {empty}
@@ -0,0 +1,34 @@
+package samples
+
+type reqBody struct {
+	Name string `json:"name"`
+
+	// Frame colour {default: black}.
+	Color string `json:"color"`
+}
+
+type pathParams struct {
+	ID int `path:"id"` // {example: 42}
+}
+
+type queryParams struct {
+	Size   int    `query:"size"` // {required} {default: 20}
+	Filter string `query:"filter"`
+}
+
+type formParams struct {
+	Name  string `form:"name"`  // {required}
+	Photo []byte `form:"photo"` // {required} {file}
+}
+
+// POST /bikes/{id}
+//
+// Request body: reqBody
+// Path: pathParams
+// Query: queryParams
+// Response 204: {empty}
+
+// PUT /bikes/{id}/photo
+//
+// Form: formParams
+// Response 204: {empty}
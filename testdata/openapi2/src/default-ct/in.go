@@ -0,0 +1,14 @@
+package ct
+
+type req struct {
+	Name string
+}
+
+type resp struct {
+	ID int
+}
+
+// POST /path
+//
+// Request body: req
+// Response 200: resp
@@ -0,0 +1,29 @@
+package params
+
+type commonHeaders struct {
+	// Unique ID for the request {required}.
+	RequestID string `header:"X-Request-ID"`
+
+	// Preferred language.
+	AcceptLanguage string `header:"Accept-Language"`
+}
+
+type commonQuery struct {
+	// Pretty-print the output.
+	Pretty bool `query:"pretty"`
+}
+
+type listQuery struct {
+	// Page number {default: 1}.
+	Page int `query:"page"`
+}
+
+// GET /bikes
+//
+// Query: listQuery
+// Response 200: {empty}
+
+// GET /health
+//
+// Default params: {none}
+// Response 200: {empty}
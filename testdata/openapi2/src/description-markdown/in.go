@@ -0,0 +1,26 @@
+package desc
+
+// bike is a bicycle.
+//
+// Frame sizes are:
+//
+//   - 50cm
+//   - 54cm
+type bike struct {
+	// Frame size in
+	// centimetres.
+	Size int
+}
+
+// GET /bikes
+//
+// List all bikes; the results are
+// paginated.
+//
+// Example:
+//
+//	curl https://example.com/bikes
+//
+// See https://example.com/docs for more information.
+//
+// Response 200: bike
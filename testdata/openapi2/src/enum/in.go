@@ -0,0 +1,17 @@
+package enum
+
+type resp struct {
+	Color string `json:"color"` // {enum: red blue}
+
+	// Size of the frame {enum: 50 54 58}.
+	Size int `json:"size"`
+}
+
+type query struct {
+	Sort string `query:"sort"` // {enum: name date}
+}
+
+// GET /path
+//
+// Query: query
+// Response 200: resp
@@ -0,0 +1,23 @@
+package req
+
+type query struct {
+	Page int `query:"page"` // {example: 2}
+}
+
+type req struct {
+	Name  string `json:"name"`  // {example: Martin}
+	Admin bool   `json:"admin"` // {example: true}
+	IDs   []int  `json:"ids"`   // {example: 1 2 3}
+}
+
+type resp struct {
+	ID int `json:"id"` // {example: 42}
+}
+
+// POST /path
+//
+// Query: query
+// Request body: req
+// Request example: {"name": "Martin", "admin": true, "ids": [1, 2]}
+// Response 200: resp
+// Response 200 example: {"id": 42}
@@ -0,0 +1,18 @@
+package path
+
+import "mime/multipart"
+
+type formRef struct {
+	// Profile picture.
+	Avatar *multipart.FileHeader `form:"avatar"`
+
+	// Holiday photos.
+	Photos []*multipart.FileHeader `form:"photos"`
+
+	Files []*multipart.FileHeader `form:"files"`
+}
+
+// POST /path
+//
+// Form: formRef
+// Response 200: {empty}
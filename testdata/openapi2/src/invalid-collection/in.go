@@ -0,0 +1,10 @@
+package path
+
+type queryRef struct {
+	ID int64 `query:"id"` // {collection: csv}
+}
+
+// GET /path
+//
+// Query: queryRef
+// Response 200: {empty}
@@ -0,0 +1,11 @@
+package req
+
+type query struct {
+	// Size of page {default: 500, range: 10-100}.
+	PageSize int `query:"page_size"`
+}
+
+// GET /path
+//
+// Query: query
+// Response 200: {empty}
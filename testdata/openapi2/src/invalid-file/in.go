@@ -0,0 +1,10 @@
+package path
+
+type reqRef struct {
+	Upload []byte // {file}
+}
+
+// POST /path
+//
+// Request body: reqRef
+// Response 200: {empty}
@@ -0,0 +1,11 @@
+package path
+
+type resp struct {
+	Counts map[string]int    `json:"counts"` // Counts per name.
+	Labels map[string]string `json:"labels"` // Labels.
+	Any    map[string]any    `json:"any"`    // Anything.
+}
+
+// GET /path
+//
+// Response 200: resp
@@ -0,0 +1,22 @@
+package req
+
+import "database/sql"
+
+// resp docs.
+type resp struct {
+	Name     string         `json:"name"`
+	Nickname *string        `json:"nickname"`
+	Email    sql.NullString `json:"email"`
+	Tags     []*string      `json:"tags"`
+	Note     string         `json:"note"` // {nullable}
+	Count    NullCount      `json:"count"`
+}
+
+// NullCount isn't from database/sql, so it's not nullable.
+type NullCount int
+
+func (NullCount) MarshalText() ([]byte, error) { return nil, nil }
+
+// POST /path
+//
+// Response 200: resp
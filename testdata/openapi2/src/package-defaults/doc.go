@@ -0,0 +1,10 @@
+// Package req handles bikes.
+//
+// kommentaar:
+//
+//	tags                 bikes
+//	prefix               /bikes
+//	auth                 none
+//	default-response 404: errorResp
+//	add-default-response 404
+package req
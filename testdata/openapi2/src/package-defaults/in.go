@@ -0,0 +1,19 @@
+package req
+
+type resp struct {
+	ID int `json:"id"`
+}
+
+type errorResp struct {
+	Error string `json:"error"`
+}
+
+// GET /{id}
+//
+// Response 200: resp
+// Response 400: {empty}
+
+// DELETE /{id} admin
+//
+// Response 204: {empty}
+// Response 404: {empty} Bike doesn't exist.
@@ -0,0 +1,13 @@
+package req
+
+type resp struct {
+	ID int `json:"id"`
+}
+
+// GET /bike/{id}
+//
+// Response 200: resp
+// Response 404: {empty} Bike not found.
+// Response 410: {empty}
+//   The bike was deleted; it can be restored
+//   with POST /bike/{id}/restore.
@@ -0,0 +1,10 @@
+package req
+
+type report struct {
+	Total int `json:"total"`
+}
+
+// GET /report
+//
+// Response 200 (text/csv): {data}
+// Response 200 (application/json): report
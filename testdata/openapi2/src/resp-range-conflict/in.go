@@ -0,0 +1,15 @@
+package req
+
+type resp struct {
+	ID int `json:"id"`
+}
+
+type errorResp struct {
+	Error string `json:"error"`
+}
+
+// GET /path
+//
+// Response 200: resp
+// Response 404 (text/plain): {data}
+// Response 4XX: errorResp
@@ -0,0 +1,19 @@
+package req
+
+type resp struct {
+	ID int `json:"id"`
+}
+
+type errorResp struct {
+	Error string `json:"error"`
+}
+
+// GET /path
+//
+// Response 200: resp
+// Response 404: {empty}
+// Response 409: resp The existing resource.
+// Response 4XX: errorResp
+// Response 503: errorResp
+// Response 5XX: errorResp
+// Response default: errorResp
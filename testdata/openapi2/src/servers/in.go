@@ -0,0 +1,5 @@
+package servers
+
+// GET /bikes
+//
+// Response 200: {empty}
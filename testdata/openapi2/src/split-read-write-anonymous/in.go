@@ -0,0 +1,14 @@
+package path
+
+type order struct {
+	Lines []struct {
+		ID       int64  `json:"id"` // {readonly}
+		Product  string `json:"product"`
+		Password string `json:"password"` // {writeonly}
+	} `json:"lines"`
+}
+
+// POST /path
+//
+// Request body: order
+// Response 200: order
@@ -0,0 +1,6 @@
+package site
+
+// GET /bikes bikes shop
+// List bikes.
+//
+// Response 200: {empty}
@@ -0,0 +1,51 @@
+package types
+
+// A bike.
+//
+// Bikes can be ridden.
+type bike struct {
+	ID   int64  `json:"id"`   // {readonly}
+	Name string `json:"name"` // {required}
+
+	// Frame colour {enum: red blue} {default: red}.
+	Color string `json:"color"`
+
+	Owner  *owner           `json:"owner"`
+	Tags   []string         `json:"tags"`
+	Parts  []part           `json:"parts"`
+	Extra  map[string]int   `json:"extra"`
+	Nested struct{ A bool } `json:"nested"`
+	Dashed string           `json:"dashed-name"`
+}
+
+type owner struct {
+	Name string `json:"name"`
+}
+
+type part struct {
+	Name string `json:"name"`
+}
+
+type pathParams struct {
+	ID int `path:"id"`
+}
+
+type queryParams struct {
+	Size   int    `query:"size"` // {required}
+	Filter string `query:"filter"`
+}
+
+// POST /bikes/{id}
+// Update a bike.
+//
+// Request body: bike
+// Path: pathParams
+// Query: queryParams
+// Response 200: bike
+// Response 404: {empty}
+
+// GET /bikes/{id}/owner
+//
+// Path: pathParams
+// Response 200 (application/json): owner
+// Response 200 (application/xml): part
@@ -0,0 +1,64 @@
+// Code generated by kommentaar; DO NOT EDIT.
+//
+// x x
+
+/**
+ * A bike.
+ *
+ * Bikes can be ridden.
+ */
+export interface TypesBike {
+	readonly id?: number;
+	name: string;
+	/**
+	 * Frame colour.
+	 *
+	 * @default "red"
+	 */
+	color?: "red" | "blue";
+	owner?: TypesOwner | null;
+	tags?: string[];
+	parts?: TypesPart[];
+	extra?: Record<string, number>;
+	nested?: {
+		A?: boolean;
+	};
+	"dashed-name"?: string;
+}
+
+export interface TypesOwner {
+	name?: string;
+}
+
+export interface TypesPart {
+	name?: string;
+}
+
+export interface TypesPathParams {
+	id: number;
+}
+
+export interface TypesQueryParams {
+	size: number;
+	filter?: string;
+}
+
+/** All endpoints, with the types for the parameters, request body, and responses. */
+export interface Endpoints {
+	"GET /bikes/{id}/owner": {
+		path: TypesPathParams;
+		responses: {
+			"200": TypesOwner | TypesPart;
+		};
+	};
+	/** Update a bike. */
+	"POST /bikes/{id}": {
+		path: TypesPathParams;
+		query: TypesQueryParams;
+		body: TypesBike;
+		responses: {
+			"200": TypesBike;
+			"404": void;
+		};
+	};
+}
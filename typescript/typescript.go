@@ -0,0 +1,268 @@
+// Package typescript outputs TypeScript type definitions.
+//
+// Every reference is exported as an interface (or a type alias if it's not an
+// object) named after the package and type: "example.entity" becomes
+// "ExampleEntity". The Endpoints interface maps every "METHOD /path" to the
+// types of its parameters, request body, and responses.
+package typescript
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"zgo.at/kommentaar/docparse"
+	"zgo.at/zstd/zstring"
+)
+
+// WriteTypeScript writes TypeScript type definitions.
+func WriteTypeScript(w io.Writer, prog *docparse.Program) error {
+	b := new(strings.Builder)
+	b.WriteString("// Code generated by kommentaar; DO NOT EDIT.\n")
+	if t := strings.TrimSpace(prog.Config.Title + " " + prog.Config.Version); t != "" {
+		fmt.Fprintf(b, "//\n// %s\n", t)
+	}
+
+	lookups := make([]string, 0, len(prog.References))
+	for k := range prog.References {
+		lookups = append(lookups, k)
+	}
+	sort.Strings(lookups)
+
+	for _, k := range lookups {
+		ref := prog.References[k]
+		if ref.Schema == nil {
+			continue
+		}
+		b.WriteString("\n")
+		comment(b, "", ref.Info, nil)
+
+		if ref.Schema.Type == "object" && ref.Schema.AdditionalProperties == nil {
+			fmt.Fprintf(b, "export interface %s %s\n", Name(k), object(ref.Schema, "", ref.Context))
+			continue
+		}
+		fmt.Fprintf(b, "export type %s = %s;\n", Name(k), typ(ref.Schema, ""))
+	}
+
+	b.WriteString("\n")
+	endpoints(b, prog)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Name gets the TypeScript name for a reference, e.g. "example.entity" becomes
+// "ExampleEntity".
+func Name(lookup string) string {
+	var b strings.Builder
+	for _, p := range reNonIdent.Split(lookup, -1) {
+		if p == "" {
+			continue
+		}
+		b.WriteString(strings.ToUpper(p[:1]) + p[1:])
+	}
+	return b.String()
+}
+
+var (
+	reNonIdent = regexp.MustCompile(`[^a-zA-Z0-9_$]+`)
+	reIdent    = regexp.MustCompile(`^[a-zA-Z_$][a-zA-Z0-9_$]*$`)
+)
+
+// Write a TSDoc comment.
+func comment(b *strings.Builder, indent, text string, def any) {
+	text = strings.TrimSpace(strings.ReplaceAll(text, "*/", `*\/`))
+	if def != nil {
+		d, _ := json.Marshal(def)
+		text = strings.TrimSpace(text + "\n\n@default " + string(d))
+	}
+	if text == "" {
+		return
+	}
+
+	lines := strings.Split(text, "\n")
+	if len(lines) == 1 {
+		fmt.Fprintf(b, "%s/** %s */\n", indent, text)
+		return
+	}
+	fmt.Fprintf(b, "%s/**\n", indent)
+	for _, l := range lines {
+		fmt.Fprintf(b, "%s%s\n", indent, strings.TrimRight(" * "+l, " "))
+	}
+	fmt.Fprintf(b, "%s */\n", indent)
+}
+
+// Object literal type with all the properties; context is the reference
+// context for top-level objects.
+func object(s *docparse.Schema, indent, context string) string {
+	if len(s.PropertyOrder) == 0 {
+		return "{}"
+	}
+
+	b := new(strings.Builder)
+	b.WriteString("{\n")
+	for _, name := range s.PropertyOrder {
+		p := s.Properties[name]
+		if p == nil || p.OmitDoc {
+			continue
+		}
+
+		comment(b, indent+"\t", p.Description, p.Default)
+
+		// Path, query, form, and header parameters have required on the
+		// property itself, rather than on the parent; path parameters are
+		// always required.
+		var req bool
+		switch context {
+		case "path":
+			req = true
+		case "query", "form", "header":
+			req = len(p.Required) > 0
+		default:
+			req = zstring.Contains(s.Required, name)
+		}
+		opt := "?"
+		if req {
+			opt = ""
+		}
+		ro := ""
+		if p.Readonly != nil && *p.Readonly {
+			ro = "readonly "
+		}
+
+		fmt.Fprintf(b, "%s\t%s%s%s: %s;\n", indent, ro, propName(name), opt, typ(p, indent+"\t"))
+	}
+	b.WriteString(indent + "}")
+	return b.String()
+}
+
+func propName(name string) string {
+	if reIdent.MatchString(name) {
+		return name
+	}
+	j, _ := json.Marshal(name)
+	return string(j)
+}
+
+// Get the TypeScript type for a schema.
+func typ(s *docparse.Schema, indent string) string {
+	var t string
+	switch {
+	case s.Reference != "":
+		t = Name(strings.TrimPrefix(s.Reference, "#/definitions/"))
+	case len(s.Enum) > 0 && s.Type != "array":
+		enum := make([]string, 0, len(s.Enum))
+		for _, e := range s.Enum {
+			j, _ := json.Marshal(e)
+			enum = append(enum, string(j))
+		}
+		t = strings.Join(enum, " | ")
+	default:
+		switch s.Type {
+		case "string":
+			t = "string"
+		case "integer", "number":
+			t = "number"
+		case "boolean":
+			t = "boolean"
+		case "file":
+			t = "Blob"
+		case "array":
+			item := "unknown"
+			if s.Items != nil {
+				item = typ(s.Items, indent)
+				if strings.Contains(item, " ") {
+					item = "(" + item + ")"
+				}
+			}
+			t = item + "[]"
+		case "object":
+			switch {
+			case s.AdditionalProperties != nil:
+				t = "Record<string, " + typ(s.AdditionalProperties, indent) + ">"
+			case len(s.Properties) > 0:
+				t = object(s, indent, "")
+			default:
+				t = "Record<string, unknown>"
+			}
+		default:
+			t = "unknown"
+		}
+	}
+
+	if s.Nullable {
+		t += " | null"
+	}
+	return t
+}
+
+// The Endpoints interface, mapping every endpoint to its types.
+func endpoints(b *strings.Builder, prog *docparse.Program) {
+	b.WriteString("/** All endpoints, with the types for the parameters, request body, and responses. */\n")
+	b.WriteString("export interface Endpoints {\n")
+	for _, e := range prog.Endpoints {
+		comment(b, "\t", e.Tagline, nil)
+		fmt.Fprintf(b, "\t%q: {\n", e.Method+" "+prog.Config.Prefix+e.Path)
+
+		for _, p := range []struct {
+			name string
+			ref  *docparse.Ref
+		}{
+			{"path", e.Request.Path},
+			{"query", e.Request.Query},
+			{"form", e.Request.Form},
+		} {
+			if p.ref != nil {
+				fmt.Fprintf(b, "\t\t%s: %s;\n", p.name, refType(p.ref))
+			}
+		}
+		if len(e.Request.Bodies) > 0 {
+			fmt.Fprintf(b, "\t\tbody: %s;\n", bodiesType(e.Request.Bodies))
+		}
+
+		codes := make([]string, 0, len(e.Responses))
+		for c := range e.Responses {
+			codes = append(codes, c)
+		}
+		sort.Strings(codes)
+		b.WriteString("\t\tresponses: {\n")
+		for _, c := range codes {
+			fmt.Fprintf(b, "\t\t\t%s: %s;\n", propName(c), bodiesType(e.Responses[c].Bodies))
+		}
+		b.WriteString("\t\t};\n")
+		b.WriteString("\t};\n")
+	}
+	b.WriteString("}\n")
+}
+
+// Get the type for the bodies of all Content-Types; this is a union if they're
+// not all the same type.
+func bodiesType(bodies map[string]*docparse.Ref) string {
+	seen := make(map[string]bool)
+	var types []string
+	for _, b := range bodies {
+		t := refType(b)
+		if !seen[t] {
+			seen[t] = true
+			types = append(types, t)
+		}
+	}
+	if len(types) == 0 {
+		return "unknown"
+	}
+	sort.Strings(types)
+	return strings.Join(types, " | ")
+}
+
+func refType(r *docparse.Ref) string {
+	if r.Empty {
+		return "void"
+	}
+	if r.Reference == "" {
+		return "unknown"
+	}
+	return Name(r.Reference)
+}
@@ -0,0 +1,28 @@
+package typescript_test
+
+import (
+	"testing"
+
+	"zgo.at/kommentaar/internal/golden"
+	"zgo.at/kommentaar/typescript"
+)
+
+func TestWriteTypeScript(t *testing.T) {
+	golden.Test(t, "../testdata/typescript/src", "want.ts", typescript.WriteTypeScript, nil)
+}
+
+func TestName(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"example.entity", "ExampleEntity"},
+		{"mail.Address", "MailAddress"},
+		{"code-samples.reqBody", "CodeSamplesReqBody"},
+		{"pkg.Type-request", "PkgTypeRequest"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			if out := typescript.Name(tt.in); out != tt.want {
+				t.Errorf("\nout:  %s\nwant: %s", out, tt.want)
+			}
+		})
+	}
+}